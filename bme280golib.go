@@ -28,8 +28,9 @@ const (
 		REGISTER_HUM_LSB    = 0xFE
 	*/
 
-	REGISTER_CALIB00 byte = 0x88
-	REGISTER_CALIB26 byte = 0xE1
+	REGISTER_CALIB00  byte = 0x88
+	REGISTER_CALIB_H1 byte = 0xA1
+	REGISTER_CALIB26  byte = 0xE1
 )
 
 // BME280Device inteface, for faking sensor if needed
@@ -40,3 +41,13 @@ type BME280Device interface {
 	SoftReset() error
 	GetCalibration() (CalibrationRegs, error) //Gets latest values
 }
+
+// BME280ConfigReader is implemented by devices that can read back configuration from chip
+type BME280ConfigReader interface {
+	ReadConfig() (BME280Config, error)
+}
+
+// BME280SampleInfoReader is implemented by devices that track is sample fresh and how old it is
+type BME280SampleInfoReader interface {
+	ReadWithInfo() (HumTempPressureMeas, SampleInfo, error)
+}
@@ -1,8 +1,6 @@
 package BME280golib
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
 	"time"
 )
@@ -13,8 +11,15 @@ const (
 )
 
 type BME280I2C struct {
-	dev   I2CDeviceLayer
-	calib CalibrationRegs
+	dev     I2CDeviceLayer
+	calib   CalibrationRegs
+	dataBuf [8]byte //Read path do not allocate
+	Policy  FreshnessPolicy
+
+	config       BME280Config //Latest written configuration
+	configured   bool
+	configuredAt time.Time
+	fresh        freshnessTracker
 }
 
 /*
@@ -57,56 +62,79 @@ func (p *BME280I2C) GetCalibration() (CalibrationRegs, error) {
 
 // readCalibration reads calibration once, for that reason this is private
 func (p *BME280I2C) readCalibration() (CalibrationRegs, error) {
-	var calib1 CalibrationRegs1
-	var calib2 CalibrationRegs2
-
-	arr, err := p.dev.ReadRegs(REGISTER_CALIB00, 24)
-	if err != nil {
-		return CalibrationRegs{}, err
-	}
-	err = binary.Read(bytes.NewReader(arr), binary.LittleEndian, &calib1)
+	arr1, err := p.dev.ReadRegs(REGISTER_CALIB00, 24)
 	if err != nil {
 		return CalibrationRegs{}, err
 	}
-	arr, err = p.dev.ReadRegs(REGISTER_CALIB26, 8)
+	arr2, err := p.dev.ReadRegs(REGISTER_CALIB26, 8)
 	if err != nil {
 		return CalibrationRegs{}, err
 	}
-
-	calib2.H2 = int16(arr[0]) | int16(arr[1])<<8
-	calib2.H3 = arr[2]
-	calib2.H4 = int16(arr[3])<<4 | int16(arr[4]&0x0F)
-	calib2.H5 = int16(arr[5])<<4 | int16(arr[4]&0xF0)>>4
-	calib2.H6 = int8(arr[6])
-	arr, err = p.dev.ReadRegs(0xA1, 1)
+	arrH1, err := p.dev.ReadRegs(REGISTER_CALIB_H1, 1)
 	if err != nil {
 		return CalibrationRegs{}, err
 	}
-	calib2.H1 = arr[0]
-	return CombineCalibrations(calib1, calib2), nil
+	return ParseCalibration(arr1, arr2, arrH1[0])
 }
 
 func (p *BME280I2C) Close() error {
 	return p.dev.Close()
 }
 
+// Configure writes configuration to chip. Invalid configuration is refused
 func (p *BME280I2C) Configure(config BME280Config) error {
-	err := p.dev.WriteReg(REGISTER_CTRL_HUM, byte(config.Oversample_humidity))
+	err := config.GotError()
+	if err != nil {
+		return fmt.Errorf("invalid configuration %w", err)
+	}
+	var arr [4]byte //ctrl_hum, status, ctrl_meas, config
+	err = p.dev.ReadRegsInto(REGISTER_CTRL_HUM, arr[:])
 	if err != nil {
 		return err
 	}
-
-	ctrlByte := byte(config.Oversample_temperature)<<5 | byte(config.Oversample_pressure)<<2 | byte(config.Mode)
-	err = p.dev.WriteReg(REGISTER_CTRL_MEAS, ctrlByte)
+	hum, meas, conf := ConfigToRegisters(config, DecodeCtrlHumRegister(arr[0]).Reserved)
+	conf.Spi3w_en = DecodeConfigRegister(arr[3]).Spi3w_en
+
+	//Writes to config register in normal mode may be ignored. Go to sleep first
+	current := DecodeCtrlMeasRegister(arr[2])
+	if current.Mode == MODE_NORMAL {
+		current.Mode = MODE_SLEEP
+		err = p.dev.WriteReg(REGISTER_CTRL_MEAS, current.Encode())
+		if err != nil {
+			return err
+		}
+	}
+	err = p.dev.WriteReg(REGISTER_CTRL_HUM, hum.Encode())
+	if err != nil {
+		return err
+	}
+	err = p.dev.WriteReg(REGISTER_CONFIG, conf.Encode())
 	if err != nil {
 		return err
 	}
-	//Minimal inactivity, no filter =0. More inactivity, less self heating
-	return p.dev.WriteReg(REGISTER_CONFIG, ((byte(config.Standby)&0x7)<<4)|((byte(config.Filter)&0x7)<<1))
+	//ctrl_hum is effective only after writing ctrl_meas
+	writeTime := time.Now()
+	err = p.dev.WriteReg(REGISTER_CTRL_MEAS, meas.Encode())
+	if err != nil {
+		p.configured = false
+		return err
+	}
+	p.config, p.configured, p.configuredAt = config, true, writeTime
+	return nil
+}
+
+// ReadConfig reads back configuration from control registers. For checking that device have not reset itself
+func (p *BME280I2C) ReadConfig() (BME280Config, error) {
+	arr, err := p.dev.ReadRegs(REGISTER_CTRL_HUM, 4) //ctrl_hum, status, ctrl_meas, config
+	if err != nil {
+		return BME280Config{}, err
+	}
+	return ConfigFromRegisters(DecodeCtrlHumRegister(arr[0]), DecodeCtrlMeasRegister(arr[2]), DecodeConfigRegister(arr[3])), nil
 }
 
 // does soft reset, for glitch etc.... after that re-write configuration
 func (p *BME280I2C) SoftReset() error {
+	p.configured = false
 	err := p.dev.WriteReg(REGISTER_RESET, 0xB6)
 	if err != nil {
 		return err
@@ -117,21 +145,22 @@ func (p *BME280I2C) SoftReset() error {
 
 // BME280ReadRaw gives non-compensated readout not really used expect some debugging, testing or research purposes. Use Read
 func (p *BME280I2C) ReadRaw() (RawMeas, error) {
-	raw, err := p.dev.ReadRegs(REGISTER_DATA, 8)
+	err := p.dev.ReadRegsInto(REGISTER_DATA, p.dataBuf[:])
 	if err != nil {
 		return RawMeas{}, err
 	}
-	return RawMeas{
-		Temperature: uint32(raw[3])<<12 | uint32(raw[4])<<4 | uint32(raw[5])>>4,
-		Pressure:    uint32(raw[0])<<12 | uint32(raw[1])<<4 | uint32(raw[2])>>4,
-		Humidity:    uint16(raw[6])<<8 | uint16(raw[7])}, nil
+	return ParseRawMeas(p.dataBuf[:]), nil
 }
 
 // BME280Read() Reads all results and do internal compensation This is how usually this is used
 func (p *BME280I2C) Read() (HumTempPressureMeas, error) {
-	raw, rawErr := p.ReadRaw()
-	if rawErr != nil {
-		return HumTempPressureMeas{}, rawErr
-	}
-	return raw.Compensate(p.calib)
+	var result HumTempPressureMeas
+	_, err := p.ReadIntoWithInfo(&result)
+	return result, err
+}
+
+// ReadInto is Read without heap allocations, for high rate polling and microcontrollers
+func (p *BME280I2C) ReadInto(result *HumTempPressureMeas) error {
+	_, err := p.ReadIntoWithInfo(result)
+	return err
 }
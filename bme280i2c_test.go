@@ -0,0 +1,78 @@
+package BME280golib
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// readLayers gives emulated bus directly and through wrapper layers. Trace is disabled by log level
+type namedLayer struct {
+	name  string
+	layer I2CDeviceLayer
+}
+
+func readLayers() []namedLayer {
+	fake := newFakeBME280()
+	retry := CreateI2CRetry(newFakeBME280(), 2, time.Millisecond)
+	fault := CreateI2CFault(newFakeBME280(), I2CFaultConfig{}, 1)
+	trace := CreateI2CTrace(newFakeBME280(), slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	layered := CreateI2CRetry(newFakeBME280(), 2, time.Millisecond)
+	layeredFault := CreateI2CFault(&layered, I2CFaultConfig{}, 1)
+	layeredTrace := CreateI2CTrace(&layeredFault, slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	return []namedLayer{
+		{"direct", fake},
+		{"retry", &retry},
+		{"fault", &fault},
+		{"trace", &trace},
+		{"retry+fault+trace", &layeredTrace},
+	}
+}
+
+func createReadDevice(tb testing.TB, layer I2CDeviceLayer) BME280I2C {
+	tb.Helper()
+	dev, err := CreateBME280I2C(layer)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	err = dev.Configure(BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_16, Oversample_temperature: OVRSAMPLE_2, Mode: MODE_NORMAL, Filter: FILTER_16})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return dev
+}
+
+func TestReadIntoAllocs(t *testing.T) {
+	for _, l := range readLayers() {
+		dev := createReadDevice(t, l.layer)
+		var meas HumTempPressureMeas
+		var err error
+		allocs := testing.AllocsPerRun(100, func() {
+			err = dev.ReadInto(&meas)
+		})
+		if err != nil {
+			t.Errorf("%s: %v", l.name, err)
+		}
+		if allocs != 0 {
+			t.Errorf("%s: ReadInto allocates %v times per read", l.name, allocs)
+		}
+	}
+}
+
+func BenchmarkReadInto(b *testing.B) {
+	for _, l := range readLayers() {
+		b.Run(l.name, func(b *testing.B) {
+			dev := createReadDevice(b, l.layer)
+			var meas HumTempPressureMeas
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := dev.ReadInto(&meas)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
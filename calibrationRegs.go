@@ -1,6 +1,8 @@
 package BME280golib
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"strings"
 )
@@ -54,6 +56,31 @@ func CombineCalibrations(c1 CalibrationRegs1, c2 CalibrationRegs2) CalibrationRe
 	}
 }
 
+/*
+ParseCalibration from register bytes
+calib00 is 24 bytes from REGISTER_CALIB00, calib26 is at least 7 bytes from REGISTER_CALIB26 and h1 is REGISTER_CALIB_H1
+*/
+func ParseCalibration(calib00 []byte, calib26 []byte, h1 byte) (CalibrationRegs, error) {
+	var calib1 CalibrationRegs1
+	var calib2 CalibrationRegs2
+
+	if len(calib00) < 24 || len(calib26) < 7 {
+		return CalibrationRegs{}, fmt.Errorf("calibration data too short %v and %v bytes", len(calib00), len(calib26))
+	}
+	err := binary.Read(bytes.NewReader(calib00[:24]), binary.LittleEndian, &calib1)
+	if err != nil {
+		return CalibrationRegs{}, err
+	}
+
+	calib2.H1 = h1
+	calib2.H2 = int16(calib26[0]) | int16(calib26[1])<<8
+	calib2.H3 = calib26[2]
+	calib2.H4 = int16(calib26[3])<<4 | int16(calib26[4]&0x0F)
+	calib2.H5 = int16(calib26[5])<<4 | int16(calib26[4]&0xF0)>>4
+	calib2.H6 = int8(calib26[6])
+	return CombineCalibrations(calib1, calib2), nil
+}
+
 // ToTable for printout
 func (a CalibrationRegs) ToTable() string {
 	var sb strings.Builder
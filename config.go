@@ -1,6 +1,7 @@
 package BME280golib
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
@@ -28,6 +29,9 @@ func (a Oversample) HowManyTimes() int {
 		OVRSAMPLE_16: 16}
 	result, haz := m[a]
 	if !haz {
+		if a <= 7 {
+			return 16 //Others are 16x by datasheet
+		}
 		return -1
 	}
 	return result
@@ -158,17 +162,31 @@ func (a FilterSetting) String() string {
 	return "invalid"
 }
 
-/*
-TODO include step response table (figure 7 at manual) here
-*/
+// Coefficient of IIR filter. 1 when filter is off, 0 on invalid setting
+func (a FilterSetting) Coefficient() int {
+	switch a {
+	case FILTER_NO:
+		return 1
+	case FILTER_2:
+		return 2
+	case FILTER_4:
+		return 4
+	case FILTER_8:
+		return 8
+	case FILTER_16:
+		return 16
+	}
+	return 0
+}
 
+// BME280Config marshals to JSON with human readable values, see configtext.go
 type BME280Config struct {
-	Oversample_humidity    Oversample //Oversample //Common for all?
-	Oversample_pressure    Oversample //Common for all?
-	Oversample_temperature Oversample //Common for all?
-	Mode                   DeviceMode
-	Standby                StandbyDurationSetting
-	Filter                 FilterSetting
+	Oversample_humidity    Oversample             `json:"oversample_humidity"`
+	Oversample_pressure    Oversample             `json:"oversample_pressure"`
+	Oversample_temperature Oversample             `json:"oversample_temperature"`
+	Mode                   DeviceMode             `json:"mode"`
+	Standby                StandbyDurationSetting `json:"standby"`
+	Filter                 FilterSetting          `json:"filter"`
 	//Forced mode?
 }
 
@@ -177,35 +195,89 @@ func (a BME280Config) String() string {
 		a.Mode, a.Filter, a.Standby, a.Oversample_humidity, a.Oversample_pressure, a.Oversample_temperature)
 }
 
-// GotError check is there bad configuration
-func (p *BME280Config) GotError() error {
-	if 7 < p.Oversample_humidity {
-		return fmt.Errorf("humidity oversample over 3bits")
+// ConfigProblem is one problem found on configuration
+type ConfigProblem struct {
+	Field   string
+	Message string
+	Warning bool //Chip accepts configuration, but results are not usable as expected
+}
+
+func (a ConfigProblem) Error() string {
+	if a.Warning {
+		return fmt.Sprintf("warning %s: %s", a.Field, a.Message)
 	}
-	if 7 < p.Oversample_pressure {
-		return fmt.Errorf("pressure oversample over 3bits")
+	return fmt.Sprintf("%s: %s", a.Field, a.Message)
+}
+
+func checkOversample(field string, ovr Oversample) []ConfigProblem {
+	if 7 < ovr {
+		return []ConfigProblem{{Field: field, Message: "oversample over 3bits"}}
 	}
-	if 7 < p.Oversample_pressure {
-		return fmt.Errorf("pressure oversample over 3bits")
+	if OVRSAMPLE_16 < ovr { //Chip accepts, datasheet tells others=16x
+		return []ConfigProblem{{Field: field, Message: fmt.Sprintf("non-standard oversample code %v works as 16x, use OVRSAMPLE_16", byte(ovr)), Warning: true}}
 	}
+	return nil
+}
+
+// Validate returns all errors and warnings at once
+func (p *BME280Config) Validate() []ConfigProblem {
+	result := []ConfigProblem{}
+	result = append(result, checkOversample("humidity", p.Oversample_humidity)...)
+	result = append(result, checkOversample("pressure", p.Oversample_pressure)...)
+	result = append(result, checkOversample("temperature", p.Oversample_temperature)...)
 
 	if p.Mode != MODE_NORMAL && p.Mode != MODE_FORCED && p.Mode != MODE_SLEEP {
-		return fmt.Errorf("invalid mode")
+		result = append(result, ConfigProblem{Field: "mode", Message: fmt.Sprintf("invalid mode %v", byte(p.Mode))})
 	}
 	if 7 < p.Standby {
-		return fmt.Errorf("standby over 3bits")
+		result = append(result, ConfigProblem{Field: "standby", Message: "standby over 3bits"})
+	}
+	if FILTER_16 < p.Filter {
+		result = append(result, ConfigProblem{Field: "filter", Message: fmt.Sprintf("invalid filter %v", byte(p.Filter))})
 	}
 
-	if 7 < p.Filter {
-		return fmt.Errorf("filter over 3bits")
+	//Semantic problems. Compensation of pressure and humidity requires temperature
+	if p.Oversample_temperature == OVRSAMPLE_NO {
+		if p.Oversample_pressure != OVRSAMPLE_NO {
+			result = append(result, ConfigProblem{Field: "temperature", Message: "temperature skipped but pressure enabled, pressure can not be compensated", Warning: true})
+		}
+		if p.Oversample_humidity != OVRSAMPLE_NO {
+			result = append(result, ConfigProblem{Field: "temperature", Message: "temperature skipped but humidity enabled, humidity can not be compensated", Warning: true})
+		}
 	}
+	if p.Mode != MODE_SLEEP && p.Oversample_temperature == OVRSAMPLE_NO && p.Oversample_pressure == OVRSAMPLE_NO && p.Oversample_humidity == OVRSAMPLE_NO {
+		result = append(result, ConfigProblem{Field: "mode", Message: "all measurements skipped", Warning: true})
+	}
+	return result
+}
 
-	return nil
+// Warnings from Validate
+func (p *BME280Config) Warnings() []ConfigProblem {
+	result := []ConfigProblem{}
+	for _, problem := range p.Validate() {
+		if problem.Warning {
+			result = append(result, problem)
+		}
+	}
+	return result
 }
 
-/*
-TODO calculate estimated sampling rate from config?
-*/
+// GotError check is there bad configuration. All errors are joined, warnings are not errors
+func (p *BME280Config) GotError() error {
+	errs := []error{}
+	for _, problem := range p.Validate() {
+		if !problem.Warning {
+			errs = append(errs, problem)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SamplingRate is typical sampling rate (Hz) on normal mode. On forced mode, rate is set by triggering
+func (p *BME280Config) SamplingRate() float64 {
+	_, measTime := p.measurementCharge()
+	return 1 / (measTime + p.Standby.Duration()).Seconds()
+}
 
 func (p *BME280Config) MeasurementDurationTypical() time.Duration {
 	result := time.Millisecond
@@ -0,0 +1,53 @@
+package BME280golib
+
+import "testing"
+
+func TestValidateOversampleCodes(t *testing.T) {
+	for code := Oversample(0); code <= 8; code++ {
+		config := BME280Config{Oversample_humidity: code, Oversample_pressure: OVRSAMPLE_1, Oversample_temperature: OVRSAMPLE_1, Mode: MODE_FORCED}
+		err := config.GotError()
+		switch {
+		case code <= OVRSAMPLE_16:
+			if err != nil || len(config.Warnings()) != 0 {
+				t.Errorf("code %v: error %v warnings %v", code, err, config.Warnings())
+			}
+		case code <= 7: //Others are 16x on datasheet
+			if err != nil {
+				t.Errorf("code %v refused %v", code, err)
+			}
+			if len(config.Warnings()) != 1 {
+				t.Errorf("code %v warnings %v", code, config.Warnings())
+			}
+			if code.HowManyTimes() != 16 || code.String() != "16x" {
+				t.Errorf("code %v is %v times %s", code, code.HowManyTimes(), code)
+			}
+			text, errMarshal := code.MarshalText()
+			if errMarshal != nil || string(text) != "16x" {
+				t.Errorf("code %v marshalled %q %v", code, text, errMarshal)
+			}
+		default:
+			if err == nil {
+				t.Errorf("code %v over 3 bits accepted", code)
+			}
+		}
+	}
+}
+
+func TestConfigureAcceptsOthers16x(t *testing.T) {
+	bus := newFakeBME280()
+	dev, err := CreateBME280I2C(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := BME280Config{Oversample_humidity: 7, Oversample_pressure: 6, Oversample_temperature: OVRSAMPLE_1, Mode: MODE_FORCED}
+	err = dev.Configure(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bus.regs[REGISTER_CTRL_HUM] != 7 || bus.regs[REGISTER_CTRL_MEAS] != 6<<2|1<<5|1 {
+		t.Errorf("ctrl_hum 0x%02X ctrl_meas 0x%02X", bus.regs[REGISTER_CTRL_HUM], bus.regs[REGISTER_CTRL_MEAS])
+	}
+	if config.MeasurementDurationMaximum() != (&BME280Config{Oversample_humidity: OVRSAMPLE_16, Oversample_pressure: OVRSAMPLE_16, Oversample_temperature: OVRSAMPLE_1}).MeasurementDurationMaximum() {
+		t.Errorf("timing of code 6 and 7 differ from 16x")
+	}
+}
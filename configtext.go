@@ -0,0 +1,115 @@
+/*
+Text marshalling of configuration enums. Allows configuration files (JSON, YAML etc..) and flags
+with human readable values like "16x", "normal", "62.5ms" or "8" instead of register codes
+*/
+package BME280golib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var oversampleNames = map[string]Oversample{
+	"skipped": OVRSAMPLE_NO, "no": OVRSAMPLE_NO, "off": OVRSAMPLE_NO, "0": OVRSAMPLE_NO, "0x": OVRSAMPLE_NO,
+	"1": OVRSAMPLE_1, "1x": OVRSAMPLE_1,
+	"2": OVRSAMPLE_2, "2x": OVRSAMPLE_2,
+	"4": OVRSAMPLE_4, "4x": OVRSAMPLE_4,
+	"8": OVRSAMPLE_8, "8x": OVRSAMPLE_8,
+	"16": OVRSAMPLE_16, "16x": OVRSAMPLE_16,
+}
+
+// MarshalText gives "skipped" or "1x".."16x". Codes 6 and 7 work as 16x on chip and are marshalled as "16x"
+func (a Oversample) MarshalText() ([]byte, error) {
+	if 7 < a {
+		return nil, fmt.Errorf("invalid oversample %v", byte(a))
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText accepts "skipped", "1x", "16x" or plain "16"
+func (p *Oversample) UnmarshalText(text []byte) error {
+	result, haz := oversampleNames[strings.ToLower(strings.TrimSpace(string(text)))]
+	if !haz {
+		return fmt.Errorf("invalid oversample %q, use skipped, 1x, 2x, 4x, 8x or 16x", text)
+	}
+	*p = result
+	return nil
+}
+
+func (a DeviceMode) MarshalText() ([]byte, error) {
+	if a != MODE_SLEEP && a != MODE_FORCED && a != MODE_NORMAL {
+		return nil, fmt.Errorf("invalid mode %v", byte(a))
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText accepts "sleep", "forced" or "normal"
+func (p *DeviceMode) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "sleep":
+		*p = MODE_SLEEP
+	case "forced":
+		*p = MODE_FORCED
+	case "normal":
+		*p = MODE_NORMAL
+	default:
+		return fmt.Errorf("invalid mode %q, use sleep, forced or normal", text)
+	}
+	return nil
+}
+
+// MarshalText gives standby in milliseconds like "0.5ms" or "62.5ms"
+func (a StandbyDurationSetting) MarshalText() ([]byte, error) {
+	dur := a.Duration()
+	if dur == 0 {
+		return nil, fmt.Errorf("invalid standby %v", byte(a))
+	}
+	return []byte(strconv.FormatFloat(durationMs(dur), 'f', -1, 64) + "ms"), nil
+}
+
+// UnmarshalText accepts go durations like "62.5ms", "1s", "500us" or plain milliseconds. Must be exactly one of options
+func (p *StandbyDurationSetting) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	dur, err := time.ParseDuration(s)
+	if err != nil {
+		ms, errFloat := strconv.ParseFloat(s, 64)
+		if errFloat != nil {
+			return fmt.Errorf("invalid standby %q", text)
+		}
+		dur = time.Duration(ms * float64(time.Millisecond))
+	}
+	result := GetStandbyDuration(dur)
+	if result.Duration() != dur {
+		return fmt.Errorf("invalid standby %q, use 0.5ms, 10ms, 20ms, 62.5ms, 125ms, 250ms, 500ms or 1000ms", text)
+	}
+	*p = result
+	return nil
+}
+
+func (a FilterSetting) MarshalText() ([]byte, error) {
+	if FILTER_16 < a {
+		return nil, fmt.Errorf("invalid filter %v", byte(a))
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText accepts "no", "off" or coefficient "2", "4", "8", "16"
+func (p *FilterSetting) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "no", "off", "0", "1":
+		*p = FILTER_NO
+	case "2":
+		*p = FILTER_2
+	case "4":
+		*p = FILTER_4
+	case "8":
+		*p = FILTER_8
+	case "16":
+		*p = FILTER_16
+	default:
+		return fmt.Errorf("invalid filter %q, use no, 2, 4, 8 or 16", text)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package BME280golib
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestConfigJSONRoundTrip(t *testing.T) {
+	for _, name := range PresetNames() {
+		preset, err := GetPreset(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(preset.Config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back BME280Config
+		err = json.Unmarshal(data, &back)
+		if err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if back != preset.Config {
+			t.Errorf("%s: round trip gave %s", preset.Config, back)
+		}
+	}
+}
+
+func TestConfigFlags(t *testing.T) {
+	conf := BME280Config{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.TextVar(&conf.Oversample_humidity, "hov", OVRSAMPLE_1, "")
+	fs.TextVar(&conf.Oversample_pressure, "pov", OVRSAMPLE_1, "")
+	fs.TextVar(&conf.Oversample_temperature, "tov", OVRSAMPLE_1, "")
+	fs.TextVar(&conf.Mode, "mode", MODE_NORMAL, "")
+	fs.TextVar(&conf.Standby, "sb", STANDBYDURATION_1000, "")
+	fs.TextVar(&conf.Filter, "filt", FILTER_NO, "")
+	err := fs.Parse([]string{"-hov", "skipped", "-pov", "16x", "-tov", "2", "-mode", "forced", "-sb", "62.5ms", "-filt", "16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := BME280Config{Oversample_humidity: OVRSAMPLE_NO, Oversample_pressure: OVRSAMPLE_16, Oversample_temperature: OVRSAMPLE_2,
+		Mode: MODE_FORCED, Standby: STANDBYDURATION_62_5, Filter: FILTER_16}
+	if conf != expected {
+		t.Errorf("flags gave %s expected %s", conf, expected)
+	}
+	for _, bad := range [][]string{{"-pov", "3x"}, {"-mode", "3"}, {"-sb", "63ms"}, {"-filt", "5"}} {
+		fs.SetOutput(io.Discard)
+		if fs.Parse(bad) == nil {
+			t.Errorf("invalid flags %v accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Differential pressure from two BME280, like over HVAC filter.
+Sensors are read synchronized with SensorManager. Static offset between sensors is removed
+with auto-zero routine, run when there is no flow (fan is off)
+
+Only forced mode is truly synchronized. On normal mode sensors run on their own cycles, conversions
+of sensors can be up to one cycle apart and changing pressure shows up as noise on difference
+*/
+package BME280golib
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	DIFFPRESSURE_HIGH string = "high"
+	DIFFPRESSURE_LOW  string = "low"
+)
+
+// DifferentialMeas is high side minus low side
+type DifferentialMeas struct {
+	Time          time.Time
+	Pressure      float64 //Pa, offset removed
+	Raw           float64 //Pa, without offset removal
+	Noise         float64 //Standard deviation of latest samples Pa, 0 until there are two samples
+	ExpectedNoise float64 //RMS noise by configuration noise model Pa
+	High          HumTempPressureMeas
+	Low           HumTempPressureMeas
+}
+
+func (a DifferentialMeas) String() string {
+	return fmt.Sprintf("%.2fPa (noise %.2fPa, expected %.2fPa)", a.Pressure, a.Noise, a.ExpectedNoise)
+}
+
+type DifferentialPressure struct {
+	Manager           SensorManager
+	Offset            float64 //Pa, high minus low without flow
+	OffsetUncertainty float64 //Standard error of offset Pa
+	NoiseWindow       int     //How many latest samples are used for noise estimate
+
+	history []float64
+}
+
+func CreateDifferentialPressure(high BME280Device, low BME280Device, config BME280Config) DifferentialPressure {
+	manager := CreateSensorManager(config)
+	manager.Add(DIFFPRESSURE_HIGH, high)
+	manager.Add(DIFFPRESSURE_LOW, low)
+	return DifferentialPressure{Manager: manager, NoiseWindow: 32}
+}
+
+func (p *DifferentialPressure) Configure() error {
+	p.history = nil
+	return p.Manager.Configure()
+}
+
+// ExpectedNoise of difference. Both sensors have independent noise
+func (p *DifferentialPressure) ExpectedNoise() float64 {
+	return math.Sqrt2 * p.Manager.Config.Estimate().PressureNoise
+}
+
+// readRaw takes synchronized sample from both sensors
+func (p *DifferentialPressure) readRaw() (DifferentialMeas, error) {
+	record := p.Manager.Sample()
+	err := record.Err()
+	if err != nil {
+		return DifferentialMeas{}, err
+	}
+	result := DifferentialMeas{Time: record.Time, High: record.Readings[0].Meas, Low: record.Readings[1].Meas}
+	result.Raw = result.High.Pressure - result.Low.Pressure
+	if math.IsInf(result.Raw, 0) || math.IsNaN(result.Raw) {
+		return result, fmt.Errorf("pressure out of range high=%v low=%v", result.High.Pressure, result.Low.Pressure)
+	}
+	return result, nil
+}
+
+// Read differential pressure
+func (p *DifferentialPressure) Read() (DifferentialMeas, error) {
+	result, err := p.readRaw()
+	if err != nil {
+		return result, err
+	}
+	result.Pressure = result.Raw - p.Offset
+	result.ExpectedNoise = p.ExpectedNoise()
+
+	p.history = append(p.history, result.Pressure)
+	if len(p.history) > max(p.NoiseWindow, 2) {
+		p.history = p.history[len(p.history)-max(p.NoiseWindow, 2):]
+	}
+	_, result.Noise = meanAndStdDev(p.history)
+	return result, nil
+}
+
+/*
+AutoZero measures offset between sensors as average of samples. Run when there is no flow.
+On normal mode waits one cycle between samples so every sample is new conversion.
+IIR filter correlates consecutive conversions (also on forced mode), OffsetUncertainty is calculated with effective sample count
+*/
+func (p *DifferentialPressure) AutoZero(samples int) error {
+	if samples < 1 {
+		return fmt.Errorf("invalid number of auto zero samples %v", samples)
+	}
+	values := make([]float64, samples)
+	for i := range values {
+		if 0 < i && p.Manager.Config.Mode == MODE_NORMAL {
+			time.Sleep(p.Manager.Config.CycleDuration())
+		}
+		meas, err := p.readRaw()
+		if err != nil {
+			return fmt.Errorf("auto zero failed %w", err)
+		}
+		values[i] = meas.Raw
+	}
+	var stdDev float64
+	p.Offset, stdDev = meanAndStdDev(values)
+	p.OffsetUncertainty = stdDev / math.Sqrt(effectiveSamples(samples, p.Manager.Config.Filter))
+	p.history = nil
+	return nil
+}
+
+func (p *DifferentialPressure) Close() error {
+	return p.Manager.Close()
+}
+
+/*
+effectiveSamples is number of independent samples in n consecutive filtered conversions.
+IIR filter output of white noise is AR(1) with lag one correlation (c-1)/c, c is filter coefficient
+*/
+func effectiveSamples(n int, filter FilterSetting) float64 {
+	c := float64(filter.Coefficient())
+	if c <= 1 {
+		return float64(n)
+	}
+	rho := (c - 1) / c
+	return max(1, float64(n)*(1-rho)/(1+rho))
+}
+
+// meanAndStdDev gives mean and sample standard deviation. Standard deviation is 0 if there are less than 2 values
+func meanAndStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	sumSq := 0.0
+	for _, v := range values {
+		sumSq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sumSq / float64(len(values)-1))
+}
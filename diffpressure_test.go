@@ -0,0 +1,131 @@
+package BME280golib
+
+import (
+	"errors"
+	"math"
+	"syscall"
+	"testing"
+)
+
+// offsetDevice adds next of offsets to pressure on each read. Only BME280Device methods, so manager uses plain Read
+type offsetDevice struct {
+	BME280Device
+	offsets []float64
+	reads   int
+}
+
+func (p *offsetDevice) Read() (HumTempPressureMeas, error) {
+	meas, err := p.BME280Device.Read()
+	if 0 < len(p.offsets) {
+		meas.Pressure += p.offsets[p.reads%len(p.offsets)]
+	}
+	p.reads++
+	return meas, err
+}
+
+var diffConfig = BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_1, Oversample_temperature: OVRSAMPLE_1, Mode: MODE_FORCED}
+
+func createDiffPressure(t *testing.T, config BME280Config) (DifferentialPressure, *fakeBME280, *offsetDevice) {
+	t.Helper()
+	highBus, lowBus := newFakeBME280(), newFakeBME280()
+	high, err := CreateBME280I2C(highBus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	low, err := CreateBME280I2C(lowBus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lowDev := &offsetDevice{BME280Device: &low}
+	diff := CreateDifferentialPressure(&high, lowDev, config)
+	err = diff.Configure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return diff, highBus, lowDev
+}
+
+func TestDiffPressureExpectedNoise(t *testing.T) {
+	diff, _, _ := createDiffPressure(t, diffConfig)
+	single := diffConfig.Estimate().PressureNoise
+	if got := diff.ExpectedNoise(); math.Abs(got-math.Sqrt2*single) > 1e-12 || got <= 0 {
+		t.Errorf("expected noise %v, single sensor %v", got, single)
+	}
+	quiet := diffConfig
+	quiet.Oversample_pressure, quiet.Filter = OVRSAMPLE_16, FILTER_16
+	diff.Manager.Config = quiet
+	if diff.ExpectedNoise() >= math.Sqrt2*single {
+		t.Errorf("oversampling and filter do not reduce expected noise %v", diff.ExpectedNoise())
+	}
+}
+
+func TestDiffPressureAutoZero(t *testing.T) {
+	diff, _, lowDev := createDiffPressure(t, diffConfig)
+	lowDev.offsets = []float64{-12.5} //Static offset between sensors
+	err := diff.AutoZero(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(diff.Offset-12.5) > 1e-6 || diff.OffsetUncertainty != 0 {
+		t.Errorf("offset %v uncertainty %v, expected 12.5Pa without uncertainty", diff.Offset, diff.OffsetUncertainty)
+	}
+
+	lowDev.offsets = []float64{-12.5 - 30} //Flow drops pressure on low side
+	meas, err := diff.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(meas.Pressure-30) > 1e-6 || math.Abs(meas.Raw-42.5) > 1e-6 || meas.Noise != 0 || meas.ExpectedNoise != diff.ExpectedNoise() {
+		t.Errorf("read %#v", meas)
+	}
+	lowDev.offsets = []float64{-12.5 - 31}
+	meas, err = diff.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(meas.Noise-math.Sqrt(0.5)) > 1e-6 { //Sample standard deviation of 30 and 31
+		t.Errorf("noise %v", meas.Noise)
+	}
+
+	if diff.AutoZero(0) == nil {
+		t.Errorf("zero samples accepted")
+	}
+}
+
+// Filtered conversions are correlated, uncertainty of offset is by effective sample count
+func TestDiffPressureAutoZeroFiltered(t *testing.T) {
+	for _, tc := range []struct {
+		filter    FilterSetting
+		effective float64 //of 8 samples
+	}{
+		{FILTER_NO, 8},
+		{FILTER_2, 8.0 / 3},
+		{FILTER_16, 1},
+	} {
+		config := diffConfig
+		config.Filter = tc.filter
+		diff, _, lowDev := createDiffPressure(t, config)
+		lowDev.offsets = []float64{-1, 1}
+		err := diff.AutoZero(8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stdDev := math.Sqrt(8.0 / 7) //Sample standard deviation of four -1 and four 1
+		if expected := stdDev / math.Sqrt(tc.effective); math.Abs(diff.OffsetUncertainty-expected) > 1e-9 || math.Abs(diff.Offset) > 1e-6 {
+			t.Errorf("%s: offset %v uncertainty %v, expected %v", tc.filter, diff.Offset, diff.OffsetUncertainty, expected)
+		}
+	}
+}
+
+func TestDiffPressureReadError(t *testing.T) {
+	diff, highBus, _ := createDiffPressure(t, diffConfig)
+	highBus.failNext(REGISTER_DATA, syscall.EIO)
+	err := diff.AutoZero(2)
+	if !errors.Is(err, syscall.EIO) {
+		t.Errorf("auto zero with failing sensor gave %v", err)
+	}
+	_, err = diff.Read()
+	if err != nil {
+		t.Errorf("read after recovered sensor %v", err)
+	}
+}
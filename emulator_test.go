@@ -0,0 +1,96 @@
+package BME280golib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// fakeBME280 is register level emulator of chip for tests. Calibration and raw values are datasheet examples
+type fakeBME280 struct {
+	mu        sync.Mutex
+	regs      [256]byte
+	writes    int
+	closed    bool
+	failReads map[byte][]error //Next reads from register fail with these
+	reads     map[byte]int     //Read count per start register
+
+	resetOnFailure bool //Chip resets when injected read failure happens, like on brownout
+}
+
+func newFakeBME280() *fakeBME280 {
+	p := &fakeBME280{failReads: make(map[byte][]error), reads: make(map[byte]int)}
+	p.regs[REGISTER_ID] = ID_EXPECTED
+	calib := []uint16{27504, 26435, 0xFC18, 36477, 0xD641, 3024, 2855, 140, 0xFFF9, 15500, 0xC6F8, 6000} //T1..T3 P1..P9
+	for i, v := range calib {
+		binary.LittleEndian.PutUint16(p.regs[int(REGISTER_CALIB00)+2*i:], v)
+	}
+	p.regs[REGISTER_CALIB_H1] = 75
+	copy(p.regs[REGISTER_CALIB26:], []byte{0x6A, 0x01, 0x00, 0x14, 0x04, 0x00, 0x1E}) //H2=362 H3=0 H4=324 H5=0 H6=30
+	p.setRaw(RawMeas{Temperature: 519888, Pressure: 415148, Humidity: 0x6A00})
+	return p
+}
+
+func (p *fakeBME280) setRaw(raw RawMeas) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d := p.regs[REGISTER_DATA:]
+	d[0], d[1], d[2] = byte(raw.Pressure>>12), byte(raw.Pressure>>4), byte(raw.Pressure<<4)
+	d[3], d[4], d[5] = byte(raw.Temperature>>12), byte(raw.Temperature>>4), byte(raw.Temperature<<4)
+	d[6], d[7] = byte(raw.Humidity>>8), byte(raw.Humidity)
+}
+
+// failNext makes next reads from register fail. Locked, for setting failures while device is used from other goroutine
+func (p *fakeBME280) failNext(address byte, errs ...error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failReads[address] = errs
+}
+
+// reset control registers to power on values and data registers to 0x80000 (skipped)
+func (p *fakeBME280) reset() {
+	p.regs[REGISTER_CTRL_HUM], p.regs[REGISTER_CTRL_MEAS], p.regs[REGISTER_CONFIG] = 0, 0, 0
+	copy(p.regs[REGISTER_DATA:], []byte{0x80, 0, 0, 0x80, 0, 0, 0x80, 0})
+}
+
+func (p *fakeBME280) WriteReg(address byte, value byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.writes++
+	if address == REGISTER_RESET {
+		if value == 0xB6 {
+			p.reset()
+		}
+		return nil
+	}
+	p.regs[address] = value
+	return nil
+}
+
+func (p *fakeBME280) ReadRegs(address byte, count byte) ([]byte, error) {
+	result := make([]byte, count)
+	return result, p.ReadRegsInto(address, result)
+}
+
+func (p *fakeBME280) ReadRegsInto(address byte, buf []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reads[address]++
+	if errs := p.failReads[address]; 0 < len(errs) {
+		p.failReads[address] = errs[1:]
+		if p.resetOnFailure {
+			p.reset()
+		}
+		return errs[0]
+	}
+	if len(p.regs) < int(address)+len(buf) {
+		return fmt.Errorf("read over register space 0x%02X+%v", address, len(buf))
+	}
+	copy(buf, p.regs[address:])
+	return nil
+}
+
+func (p *fakeBME280) Close() error {
+	p.closed = true
+	return nil
+}
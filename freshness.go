@@ -0,0 +1,152 @@
+/*
+Stale and duplicate sample detection.
+When polling faster than measurement cycle, data registers have same conversion as on previous read.
+Raw data and read times are tracked so age of sample can be estimated
+*/
+package BME280golib
+
+import "time"
+
+type FreshnessPolicy byte
+
+const (
+	FRESHPOLICY_REREAD FreshnessPolicy = iota //Always read registers
+	FRESHPOLICY_CACHED                        //Return cached sample without bus access if new conversion is not expected yet
+	FRESHPOLICY_BLOCK                         //Wait until new conversion is expected and read it
+)
+
+func (a FreshnessPolicy) String() string {
+	switch a {
+	case FRESHPOLICY_REREAD:
+		return "reread"
+	case FRESHPOLICY_CACHED:
+		return "cached"
+	case FRESHPOLICY_BLOCK:
+		return "block"
+	}
+	return "INVALID"
+}
+
+// SampleInfo tells is reading new. Identical consecutive conversions are reported as not fresh (rare, temperature have noise)
+type SampleInfo struct {
+	Fresh       bool          //Data registers changed since previous read
+	Age         time.Duration //Estimated time since conversion was completed
+	Cached      bool          //Returned from cache, registers were not read
+	Completed   time.Time     //Estimated time when conversion was completed. Zero if not known, like on first read
+	Uncertainty time.Duration //Completed is accurate within ±Uncertainty
+}
+
+type freshnessTracker struct {
+	valid       bool
+	raw         [8]byte
+	meas        HumTempPressureMeas
+	lastRead    time.Time
+	changeSeen  time.Time //Read where latest change was seen
+	completed   time.Time //Estimated completion time of latest conversion
+	uncertainty time.Duration
+}
+
+/*
+nextConversion is when next conversion is expected to be completed. Zero time if not coming (forced mode already read,
+sleep mode) or not configured. If latest conversion is not known, first conversion after configuration is expected.
+That time is in past when data is still unknown, so it is read again
+*/
+func (p *BME280I2C) nextConversion() time.Time {
+	if !p.configured || p.config.Mode == MODE_SLEEP {
+		return time.Time{}
+	}
+	if !p.fresh.valid || p.fresh.completed.IsZero() || p.fresh.changeSeen.Before(p.configuredAt) {
+		return p.configuredAt.Add(p.config.MeasurementDurationMaximum())
+	}
+	if p.config.Mode == MODE_NORMAL {
+		return p.fresh.completed.Add(p.config.CycleDuration())
+	}
+	return time.Time{} //Forced conversion is already read
+}
+
+// earliestCompletion gives earliest possible completion time of conversion seen first at readTime. Zero if not known
+func (p *BME280I2C) earliestCompletion(readTime time.Time) time.Time {
+	result := p.fresh.lastRead
+	if p.configured {
+		if result.Before(p.configuredAt) {
+			_, measurement := p.config.measurementCharge()
+			if readTime.Before(p.configuredAt.Add(measurement)) {
+				return time.Time{} //Data is from before configuration
+			}
+			result = p.configuredAt
+		}
+		if limit := readTime.Add(-p.config.CycleDuration()); p.config.Mode == MODE_NORMAL && result.Before(limit) {
+			result = limit
+		}
+	}
+	return result
+}
+
+// age of latest conversion, 0 if not known
+func (p *BME280I2C) age(now time.Time) time.Duration {
+	if p.fresh.completed.IsZero() {
+		return 0
+	}
+	return now.Sub(p.fresh.completed)
+}
+
+// readTracked reads data registers and updates freshness tracking
+func (p *BME280I2C) readTracked(result *HumTempPressureMeas) (SampleInfo, error) {
+	raw, err := p.ReadRaw()
+	if err != nil {
+		return SampleInfo{}, err
+	}
+	now := time.Now()
+	info := SampleInfo{Fresh: !p.fresh.valid || p.fresh.raw != p.dataBuf}
+	if info.Fresh {
+		p.fresh.completed, p.fresh.uncertainty = p.estimateCompletion(now)
+		p.fresh.changeSeen = now
+		p.fresh.raw = p.dataBuf
+		p.fresh.meas, err = raw.Compensate(p.calib)
+		p.fresh.valid = err == nil
+	}
+	p.fresh.lastRead = now
+	info.Age = p.age(now)
+	info.Completed, info.Uncertainty = p.fresh.completed, p.fresh.uncertainty
+	*result = p.fresh.meas
+	return info, err
+}
+
+/*
+ReadInto with sample info. Read and ReadInto follow same Policy.
+On FRESHPOLICY_BLOCK waits at most two cycles, returns not fresh sample if there is no new conversion coming (forced mode already read)
+*/
+func (p *BME280I2C) ReadIntoWithInfo(result *HumTempPressureMeas) (SampleInfo, error) {
+	next := p.nextConversion()
+	now := time.Now()
+
+	switch p.Policy {
+	case FRESHPOLICY_CACHED:
+		//Unknown completion time is read again, cache is used only when timing is known
+		if p.configured && p.fresh.valid && !p.fresh.completed.IsZero() && (next.IsZero() || now.Before(next)) {
+			*result = p.fresh.meas
+			return SampleInfo{Age: p.age(now), Cached: true, Completed: p.fresh.completed, Uncertainty: p.fresh.uncertainty}, nil
+		}
+	case FRESHPOLICY_BLOCK:
+		if next.IsZero() {
+			break
+		}
+		time.Sleep(time.Until(next))
+		deadline := next.Add(p.config.CycleDuration())
+		step := max(p.config.CycleDuration()/16, time.Millisecond)
+		for {
+			info, err := p.readTracked(result)
+			if err != nil || info.Fresh || time.Now().After(deadline) {
+				return info, err
+			}
+			time.Sleep(step)
+		}
+	}
+	return p.readTracked(result)
+}
+
+func (p *BME280I2C) ReadWithInfo() (HumTempPressureMeas, SampleInfo, error) {
+	var result HumTempPressureMeas
+	info, err := p.ReadIntoWithInfo(&result)
+	return result, info, err
+}
@@ -0,0 +1,109 @@
+package BME280golib
+
+import (
+	"testing"
+	"time"
+)
+
+func createPolicyDevice(t *testing.T, policy FreshnessPolicy, mode DeviceMode) (*fakeBME280, BME280I2C) {
+	t.Helper()
+	bus := newFakeBME280()
+	dev, err := CreateBME280I2C(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.Policy = policy
+	err = dev.Configure(BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_1, Oversample_temperature: OVRSAMPLE_1, Mode: mode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bus, dev
+}
+
+// Read right after Configure gives data from before configuration. Completion is not known, cache must not be used
+func TestCachedPolicyUnknownCompletionNormal(t *testing.T) {
+	bus, dev := createPolicyDevice(t, FRESHPOLICY_CACHED, MODE_NORMAL)
+	_, info, err := dev.ReadWithInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Completed.IsZero() {
+		t.Fatalf("completion %v known on read right after configure", info.Completed)
+	}
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond) //Over two cycles
+		_, info, err = dev.ReadWithInfo()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Cached {
+			t.Errorf("read #%v served from cache, completion of cached conversion is not known", i)
+		}
+	}
+	if bus.reads[REGISTER_DATA] != 6 {
+		t.Errorf("data registers read %v times, expected 6", bus.reads[REGISTER_DATA])
+	}
+}
+
+// Forced conversion that is already read is not coming again, registers are not read
+func TestCachedPolicyForcedRead(t *testing.T) {
+	bus, dev := createPolicyDevice(t, FRESHPOLICY_CACHED, MODE_FORCED)
+	time.Sleep(dev.config.MeasurementDurationMaximum())
+	_, info, err := dev.ReadWithInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Cached || info.Completed.IsZero() {
+		t.Fatalf("forced conversion read as %+v", info)
+	}
+	for i := 0; i < 3; i++ {
+		_, info, err = dev.ReadWithInfo()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.Cached {
+			t.Errorf("read #%v not from cache", i)
+		}
+	}
+	if bus.reads[REGISTER_DATA] != 1 {
+		t.Errorf("data registers read %v times, expected 1", bus.reads[REGISTER_DATA])
+	}
+
+	//New trigger, next read must go to bus
+	err = dev.TriggerForced()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(dev.config.MeasurementDurationMaximum())
+	bus.setRaw(RawMeas{Temperature: 519900, Pressure: 415100, Humidity: 0x6A10})
+	_, info, err = dev.ReadWithInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Cached || !info.Fresh {
+		t.Errorf("triggered conversion read as %+v", info)
+	}
+}
+
+func TestBlockPolicyWaitsNewConversion(t *testing.T) {
+	bus, dev := createPolicyDevice(t, FRESHPOLICY_BLOCK, MODE_NORMAL)
+	time.Sleep(dev.config.MeasurementDurationMaximum())
+	_, info, err := dev.ReadWithInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Fresh || info.Completed.IsZero() {
+		t.Fatalf("first conversion read as %+v", info)
+	}
+	go func() {
+		time.Sleep(dev.config.MeasurementDurationTypical())
+		bus.setRaw(RawMeas{Temperature: 519900, Pressure: 415100, Humidity: 0x6A10})
+	}()
+	_, info, err = dev.ReadWithInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Fresh {
+		t.Errorf("block policy returned old conversion %+v", info)
+	}
+}
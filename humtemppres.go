@@ -9,6 +9,14 @@ type RawMeas struct {
 	Humidity    uint16 //16bit
 }
 
+// ParseRawMeas from 8 bytes read from REGISTER_DATA (press_msb...hum_lsb)
+func ParseRawMeas(raw []byte) RawMeas {
+	return RawMeas{
+		Temperature: uint32(raw[3])<<12 | uint32(raw[4])<<4 | uint32(raw[5])>>4,
+		Pressure:    uint32(raw[0])<<12 | uint32(raw[1])<<4 | uint32(raw[2])>>4,
+		Humidity:    uint16(raw[6])<<8 | uint16(raw[7])}
+}
+
 type HumTempPressureMeas struct {
 	Temperature float64
 	Rh          float64
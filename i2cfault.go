@@ -0,0 +1,136 @@
+/*
+Fault injection layer for resilience testing.
+Wraps any I2CDeviceLayer and injects faults. Faults are reproducible from seed
+*/
+package BME280golib
+
+import (
+	"fmt"
+	"math/rand"
+	"syscall"
+	"time"
+)
+
+// I2CFaultConfig tells what faults are injected. Probabilities are per operation (bit flip per data byte). 0=disabled
+type I2CFaultConfig struct {
+	ErrorProbability       float64
+	Errnos                 []syscall.Errno //Errors picked randomly. Empty=DefaultRetryErrnos
+	ShortReadProbability   float64         //Read gets fewer bytes than requested
+	BitFlipProbability     float64         //Flips one random bit on data byte read
+	StuckProbability       float64         //Bus gets stuck, StuckOperations following operations fail too
+	StuckOperations        int             //Counted in operations, not time, so seeded run is reproducible
+	WrongChipIDProbability float64         //Read of ID register gives WrongChipID
+	WrongChipID            byte
+	ResetProbability       float64 //Spontaneous reset, soft reset is written to device
+}
+
+// I2CFaultStats counts injected faults
+type I2CFaultStats struct {
+	Errors     int
+	ShortReads int
+	BitFlips   int
+	Stucks     int
+	WrongIDs   int
+	Resets     int
+}
+
+func (a I2CFaultStats) String() string {
+	return fmt.Sprintf("errors:%v short reads:%v bit flips:%v stucks:%v wrong ids:%v resets:%v",
+		a.Errors, a.ShortReads, a.BitFlips, a.Stucks, a.WrongIDs, a.Resets)
+}
+
+type I2CFault struct {
+	dev       I2CDeviceLayer
+	Faults    I2CFaultConfig
+	Stats     I2CFaultStats
+	rnd       *rand.Rand
+	stuckLeft int
+}
+
+func CreateI2CFault(dev I2CDeviceLayer, faults I2CFaultConfig, seed int64) I2CFault {
+	return I2CFault{dev: dev, Faults: faults, rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (p *I2CFault) happens(probability float64) bool {
+	return 0 < probability && p.rnd.Float64() < probability
+}
+
+// common faults for all operations. Returns error if operation should fail
+func (p *I2CFault) inject() error {
+	if 0 < p.stuckLeft {
+		p.stuckLeft--
+		return fmt.Errorf("injected stuck bus %w", syscall.ETIMEDOUT)
+	}
+	if p.happens(p.Faults.StuckProbability) {
+		p.Stats.Stucks++
+		p.stuckLeft = p.Faults.StuckOperations
+		return fmt.Errorf("injected stuck bus %w", syscall.ETIMEDOUT)
+	}
+	if p.happens(p.Faults.ResetProbability) {
+		p.Stats.Resets++
+		err := p.dev.WriteReg(REGISTER_RESET, 0xB6)
+		if err != nil {
+			return fmt.Errorf("injecting reset failed %w", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if p.happens(p.Faults.ErrorProbability) {
+		p.Stats.Errors++
+		errnos := p.Faults.Errnos
+		if len(errnos) == 0 {
+			errnos = DefaultRetryErrnos
+		}
+		return fmt.Errorf("injected %w", errnos[p.rnd.Intn(len(errnos))])
+	}
+	return nil
+}
+
+func (p *I2CFault) WriteReg(address byte, value byte) error {
+	err := p.inject()
+	if err != nil {
+		return err
+	}
+	return p.dev.WriteReg(address, value)
+}
+
+func (p *I2CFault) ReadRegs(address byte, count byte) ([]byte, error) {
+	result := make([]byte, count)
+	return result, p.ReadRegsInto(address, result)
+}
+
+func (p *I2CFault) ReadRegsInto(address byte, buf []byte) error {
+	err := p.inject()
+	if err != nil {
+		return err
+	}
+	err = p.dev.ReadRegsInto(address, buf)
+	if err != nil {
+		return err
+	}
+
+	if 0 < len(buf) && p.happens(p.Faults.ShortReadProbability) {
+		p.Stats.ShortReads++
+		n := p.rnd.Intn(len(buf))
+		for i := n; i < len(buf); i++ {
+			buf[i] = 0
+		}
+		return fmt.Errorf("injected short read %v of %v bytes", n, len(buf))
+	}
+
+	for i := range buf {
+		if p.happens(p.Faults.BitFlipProbability) {
+			p.Stats.BitFlips++
+			buf[i] ^= 1 << p.rnd.Intn(8)
+		}
+	}
+
+	if address <= REGISTER_ID && int(REGISTER_ID) < int(address)+len(buf) && p.happens(p.Faults.WrongChipIDProbability) {
+		p.Stats.WrongIDs++
+		buf[REGISTER_ID-address] = p.Faults.WrongChipID
+	}
+	return nil
+}
+
+func (p *I2CFault) Close() error {
+	return p.dev.Close()
+}
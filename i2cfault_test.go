@@ -0,0 +1,78 @@
+package BME280golib
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+var allFaults = I2CFaultConfig{
+	ErrorProbability:       0.05,
+	ShortReadProbability:   0.05,
+	BitFlipProbability:     0.01,
+	StuckProbability:       0.02,
+	StuckOperations:        4,
+	WrongChipIDProbability: 0.1,
+	WrongChipID:            0x58,
+	ResetProbability:       0.01,
+}
+
+// runFaultSession runs same operations than driver does and logs outcome of each
+func runFaultSession(seed int64) ([]string, I2CFaultStats) {
+	fault := CreateI2CFault(newFakeBME280(), allFaults, seed)
+	log := []string{}
+	buf := make([]byte, 8)
+	for i := 0; i < 300; i++ {
+		var err error
+		switch i % 3 {
+		case 0:
+			err = fault.WriteReg(REGISTER_CTRL_MEAS, 0x27)
+			log = append(log, fmt.Sprintf("W %v", err))
+		case 1:
+			err = fault.ReadRegsInto(REGISTER_ID, buf[:1])
+			log = append(log, fmt.Sprintf("R %X %v", buf[:1], err))
+		case 2:
+			err = fault.ReadRegsInto(REGISTER_DATA, buf)
+			log = append(log, fmt.Sprintf("R %X %v", buf, err))
+		}
+	}
+	return log, fault.Stats
+}
+
+func TestFaultReproducible(t *testing.T) {
+	log1, stats1 := runFaultSession(42)
+	log2, stats2 := runFaultSession(42)
+	if !reflect.DeepEqual(log1, log2) {
+		t.Errorf("same seed gives different fault sequence")
+	}
+	if stats1 != stats2 {
+		t.Errorf("same seed gives different stats %s and %s", stats1, stats2)
+	}
+	if stats1.Errors == 0 || stats1.ShortReads == 0 || stats1.Stucks == 0 || stats1.WrongIDs == 0 {
+		t.Errorf("faults not injected %s", stats1)
+	}
+	log3, _ := runFaultSession(43)
+	if reflect.DeepEqual(log1, log3) {
+		t.Errorf("different seed gives same fault sequence")
+	}
+}
+
+func TestFaultStuckOperations(t *testing.T) {
+	fault := CreateI2CFault(newFakeBME280(), I2CFaultConfig{StuckProbability: 1, StuckOperations: 3}, 1)
+	for i := 0; i < 8; i++ {
+		err := fault.WriteReg(REGISTER_CTRL_MEAS, 0)
+		if !errors.Is(err, syscall.ETIMEDOUT) {
+			t.Fatalf("operation %v on stuck bus gave %v", i, err)
+		}
+	}
+	if fault.Stats.Stucks != 2 { //Each stuck lasts 1+3 operations
+		t.Errorf("stucks %v", fault.Stats.Stucks)
+	}
+
+	fault = CreateI2CFault(newFakeBME280(), I2CFaultConfig{}, 1)
+	if err := fault.WriteReg(REGISTER_CTRL_MEAS, 0); err != nil {
+		t.Errorf("no faults configured, got %v", err)
+	}
+}
@@ -6,5 +6,6 @@ package BME280golib
 type I2CDeviceLayer interface {
 	WriteReg(address byte, value byte) error
 	ReadRegs(address byte, count byte) ([]byte, error)
+	ReadRegsInto(address byte, buf []byte) error //Reads len(buf) bytes without allocating
 	Close() error
 }
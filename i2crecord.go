@@ -0,0 +1,298 @@
+/*
+Recording and replaying I2C transactions.
+Session recorded from real hardware can be replayed without hardware. Replay verifies that same
+register accesses are made and gives recorded bytes back. Bug reports from field can be reproduced this way
+
+Session file is text, one transaction per line. Empty lines and lines starting with # are skipped
+
+	W F4 37                      write 0x37 to 0xF4
+	R F7 8 8000008000008000      read 8 bytes from 0xF7
+	R D0 1 ERR 5 input/output error   failed with errno 5
+	C                            close
+
+Number after ERR is errno, 0 if error was not errno. Replayed error unwraps to same errno, so
+retry policies behave as on recorded session
+*/
+package BME280golib
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+type I2CTransactionKind byte
+
+const (
+	I2CTRANSACTION_WRITE I2CTransactionKind = 'W'
+	I2CTRANSACTION_READ  I2CTransactionKind = 'R'
+	I2CTRANSACTION_CLOSE I2CTransactionKind = 'C'
+)
+
+// I2CTransaction is one operation on I2CDeviceLayer
+type I2CTransaction struct {
+	Kind    I2CTransactionKind
+	Address byte
+	Count   byte          //Requested byte count on read
+	Data    []byte        //Written value or read result
+	Err     string        //Error message, empty if succeeded
+	Errno   syscall.Errno //Errno of error, 0 if not errno
+}
+
+func (a I2CTransaction) String() string {
+	var s string
+	switch a.Kind {
+	case I2CTRANSACTION_WRITE:
+		s = fmt.Sprintf("W %02X %s", a.Address, strings.ToUpper(hex.EncodeToString(a.Data)))
+	case I2CTRANSACTION_READ:
+		s = fmt.Sprintf("R %02X %v", a.Address, a.Count)
+		if a.Err == "" {
+			s += " " + strings.ToUpper(hex.EncodeToString(a.Data))
+		}
+	case I2CTRANSACTION_CLOSE:
+		s = "C"
+	default:
+		return "INVALID"
+	}
+	if a.Err != "" {
+		s += fmt.Sprintf(" ERR %v %s", uint(a.Errno), a.Err)
+	}
+	return s
+}
+
+// RecordedError is error from recorded session. Unwraps to errno like RemoteError
+type RecordedError struct {
+	Errno   syscall.Errno //0 if error was not errno
+	Message string
+}
+
+func (a RecordedError) Error() string {
+	return a.Message
+}
+
+func (a RecordedError) Unwrap() error {
+	if a.Errno == 0 {
+		return nil
+	}
+	return a.Errno
+}
+
+// Error returns recorded error as error
+func (a I2CTransaction) Error() error {
+	if a.Err == "" {
+		return nil
+	}
+	return RecordedError{Errno: a.Errno, Message: a.Err}
+}
+
+// setErr stores error message and errno for recording
+func (a *I2CTransaction) setErr(err error) {
+	if err == nil {
+		return
+	}
+	a.Err = err.Error()
+	errors.As(err, &a.Errno) //Stays 0 if not errno
+}
+
+// ParseI2CTransaction parses one line of session file
+func ParseI2CTransaction(line string) (I2CTransaction, error) {
+	result := I2CTransaction{}
+	line = strings.TrimSpace(line)
+	if i := strings.Index(line, " ERR "); 0 <= i {
+		result.Err = line[i+5:]
+		line = line[:i]
+		//Errno is optional, old sessions have only message
+		errnoText, msg, found := strings.Cut(result.Err, " ")
+		if errno, errParse := strconv.ParseUint(errnoText, 10, 16); errParse == nil && found {
+			result.Errno, result.Err = syscall.Errno(errno), msg
+		}
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return result, fmt.Errorf("empty transaction")
+	}
+	result.Kind = I2CTransactionKind(fields[0][0])
+	switch {
+	case result.Kind == I2CTRANSACTION_CLOSE && len(fields) == 1:
+		return result, nil
+	case result.Kind == I2CTRANSACTION_WRITE && len(fields) == 3:
+	case result.Kind == I2CTRANSACTION_READ && (len(fields) == 3 && result.Err != "" || len(fields) == 4):
+		count, err := strconv.ParseUint(fields[2], 10, 8)
+		if err != nil {
+			return result, fmt.Errorf("invalid read count %s", fields[2])
+		}
+		result.Count = byte(count)
+	default:
+		return result, fmt.Errorf("invalid transaction %q", line)
+	}
+
+	addr, err := strconv.ParseUint(fields[1], 16, 8)
+	if err != nil {
+		return result, fmt.Errorf("invalid register address %s", fields[1])
+	}
+	result.Address = byte(addr)
+
+	data := fields[len(fields)-1]
+	if result.Kind == I2CTRANSACTION_READ && len(fields) == 3 {
+		return result, nil //Failed read, no data
+	}
+	result.Data, err = hex.DecodeString(data)
+	if err != nil {
+		return result, fmt.Errorf("invalid data %s %v", data, err.Error())
+	}
+	if result.Kind == I2CTRANSACTION_WRITE && len(result.Data) != 1 {
+		return result, fmt.Errorf("write must have one byte, got %s", data)
+	}
+	if result.Kind == I2CTRANSACTION_READ && len(result.Data) != int(result.Count) {
+		return result, fmt.Errorf("read count %v but got %v bytes", result.Count, len(result.Data))
+	}
+	return result, nil
+}
+
+// ReadI2CTransactions reads whole session file
+func ReadI2CTransactions(r io.Reader) ([]I2CTransaction, error) {
+	result := []I2CTransaction{}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		t, err := ParseI2CTransaction(line)
+		if err != nil {
+			return result, fmt.Errorf("line %v: %w", lineNumber, err)
+		}
+		result = append(result, t)
+	}
+	return result, scanner.Err()
+}
+
+/*
+I2CRecorder wraps I2CDeviceLayer and writes all transactions to writer.
+Failure on writing session does not stop device operation, check RecordErr
+*/
+type I2CRecorder struct {
+	dev       I2CDeviceLayer
+	w         io.Writer
+	recordErr error
+}
+
+func CreateI2CRecorder(dev I2CDeviceLayer, w io.Writer) I2CRecorder {
+	return I2CRecorder{dev: dev, w: w}
+}
+
+// RecordErr gives first error on writing session
+func (p *I2CRecorder) RecordErr() error {
+	return p.recordErr
+}
+
+func (p *I2CRecorder) record(t I2CTransaction) {
+	if p.recordErr != nil {
+		return
+	}
+	_, p.recordErr = fmt.Fprintln(p.w, t.String())
+}
+
+func (p *I2CRecorder) WriteReg(address byte, value byte) error {
+	err := p.dev.WriteReg(address, value)
+	t := I2CTransaction{Kind: I2CTRANSACTION_WRITE, Address: address, Data: []byte{value}}
+	t.setErr(err)
+	p.record(t)
+	return err
+}
+
+func (p *I2CRecorder) ReadRegs(address byte, count byte) ([]byte, error) {
+	result := make([]byte, count)
+	return result, p.ReadRegsInto(address, result)
+}
+
+func (p *I2CRecorder) ReadRegsInto(address byte, buf []byte) error {
+	err := p.dev.ReadRegsInto(address, buf)
+	t := I2CTransaction{Kind: I2CTRANSACTION_READ, Address: address, Count: byte(len(buf)), Data: buf}
+	t.setErr(err)
+	p.record(t)
+	return err
+}
+
+func (p *I2CRecorder) Close() error {
+	err := p.dev.Close()
+	t := I2CTransaction{Kind: I2CTRANSACTION_CLOSE}
+	t.setErr(err)
+	p.record(t)
+	return err
+}
+
+/*
+I2CReplay implements I2CDeviceLayer by replaying recorded session.
+Each access must match next recorded transaction, otherwise error is returned
+*/
+type I2CReplay struct {
+	transactions []I2CTransaction
+	pos          int
+}
+
+func CreateI2CReplay(transactions []I2CTransaction) I2CReplay {
+	return I2CReplay{transactions: transactions}
+}
+
+// Remaining tells how many recorded transactions are not replayed yet. Zero when whole session is replayed
+func (p *I2CReplay) Remaining() int {
+	return len(p.transactions) - p.pos
+}
+
+// next checks that access matches with next recorded transaction
+func (p *I2CReplay) next(got I2CTransaction) (I2CTransaction, error) {
+	if len(p.transactions) <= p.pos {
+		return I2CTransaction{}, fmt.Errorf("replay ended, got %s", got)
+	}
+	expected := p.transactions[p.pos]
+	if expected.Kind != got.Kind || expected.Address != got.Address || expected.Count != got.Count ||
+		(got.Kind == I2CTRANSACTION_WRITE && expected.Data[0] != got.Data[0]) {
+		return expected, fmt.Errorf("replay mismatch at #%v: expected %s got %s", p.pos, expected, got)
+	}
+	p.pos++
+	return expected, nil
+}
+
+func (p *I2CReplay) WriteReg(address byte, value byte) error {
+	t, err := p.next(I2CTransaction{Kind: I2CTRANSACTION_WRITE, Address: address, Data: []byte{value}})
+	if err != nil {
+		return err
+	}
+	return t.Error()
+}
+
+func (p *I2CReplay) ReadRegs(address byte, count byte) ([]byte, error) {
+	result := make([]byte, count)
+	return result, p.ReadRegsInto(address, result)
+}
+
+func (p *I2CReplay) ReadRegsInto(address byte, buf []byte) error {
+	t, err := p.next(I2CTransaction{Kind: I2CTRANSACTION_READ, Address: address, Count: byte(len(buf))})
+	if err != nil {
+		return err
+	}
+	if t.Err != "" {
+		return t.Error()
+	}
+	copy(buf, t.Data)
+	return nil
+}
+
+func (p *I2CReplay) Close() error {
+	if p.pos == len(p.transactions) { //Session recorded without closing
+		return nil
+	}
+	t, err := p.next(I2CTransaction{Kind: I2CTRANSACTION_CLOSE})
+	if err != nil {
+		return err
+	}
+	return t.Error()
+}
@@ -0,0 +1,114 @@
+package BME280golib
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// runSession is what application does: create, configure and read
+func runSession(t *testing.T, layer I2CDeviceLayer, reads int) []HumTempPressureMeas {
+	t.Helper()
+	dev, err := CreateBME280I2C(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dev.Configure(BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_1, Oversample_temperature: OVRSAMPLE_1, Mode: MODE_NORMAL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make([]HumTempPressureMeas, reads)
+	for i := range result {
+		result[i], err = dev.Read()
+		if err != nil {
+			t.Fatalf("read #%v failed %v", i, err)
+		}
+	}
+	err = dev.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestRecordReplayRetriedErrno(t *testing.T) {
+	bus := newFakeBME280()
+	bus.failReads[REGISTER_DATA] = []error{ERRNO_EREMOTEIO}
+	var session bytes.Buffer
+	recorder := CreateI2CRecorder(bus, &session)
+	retry := CreateI2CRetry(&recorder, 2, time.Millisecond)
+	recorded := runSession(t, &retry, 3)
+	if recorder.RecordErr() != nil {
+		t.Fatal(recorder.RecordErr())
+	}
+	if !strings.Contains(session.String(), "R F7 8 ERR 121 ") {
+		t.Fatalf("errno not recorded:\n%s", session.String())
+	}
+
+	transactions, err := ReadI2CTransactions(&session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := CreateI2CReplay(transactions)
+	retryReplay := CreateI2CRetry(&replay, 2, time.Millisecond)
+	replayed := runSession(t, &retryReplay, 3)
+	if replay.Remaining() != 0 {
+		t.Errorf("%v transactions not replayed", replay.Remaining())
+	}
+	for i := range recorded {
+		if recorded[i] != replayed[i] {
+			t.Errorf("read #%v recorded %v replayed %v", i, recorded[i], replayed[i])
+		}
+	}
+}
+
+func TestParseRecordedErrno(t *testing.T) {
+	cases := []struct {
+		line  string
+		errno syscall.Errno
+		msg   string
+	}{
+		{"R D0 1 ERR 121 remote I/O error", ERRNO_EREMOTEIO, "remote I/O error"},
+		{"W F4 27 ERR 0 short write", 0, "short write"},
+		{"R D0 1 ERR input/output error", 0, "input/output error"}, //Session recorded without errno
+	}
+	for _, c := range cases {
+		tr, err := ParseI2CTransaction(c.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tr.Errno != c.errno || tr.Err != c.msg {
+			t.Errorf("%q parsed errno %v message %q", c.line, tr.Errno, tr.Err)
+		}
+		if !errors.Is(tr.Error(), c.errno) && c.errno != 0 {
+			t.Errorf("%q replayed error %v does not unwrap to errno", c.line, tr.Error())
+		}
+	}
+}
+
+// Field session from testdata must replay through retry layer like on device
+func TestReplayFieldSession(t *testing.T) {
+	f, err := os.Open("testdata/retry_session.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	transactions, err := ReadI2CTransactions(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := CreateI2CReplay(transactions)
+	retry := CreateI2CRetry(&replay, 2, time.Millisecond)
+	meas := runSession(t, &retry, 3)
+	if replay.Remaining() != 0 {
+		t.Errorf("%v transactions not replayed", replay.Remaining())
+	}
+	//Datasheet example values
+	if meas[0].Temperature < 25.07 || 25.09 < meas[0].Temperature || meas[0].Pressure < 100653 || 100654 < meas[0].Pressure {
+		t.Errorf("unexpected measurement %v", meas[0])
+	}
+}
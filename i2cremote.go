@@ -0,0 +1,274 @@
+/*
+Remote I2CDeviceLayer over framed byte stream (TCP connection, serial line etc..)
+
+Request frame
+
+	0xB2 | seq | cmd | register | n | checksum
+
+cmd is 'W' (n=value), 'R' (n=byte count) or 'C' (close session)
+
+Response frame
+
+	0xB2 | seq | status | length | data... | checksum
+
+seq is sequence number of request, response echoes it. Client skips responses with other seq, so
+response arriving after timeout is not taken as response of next request
+
+status 0=ok, data is read result. status 1=error, data is errno byte (0=not errno) and message
+
+checksum is XOR of all bytes after 0xB2. Reader skips bytes until 0xB2 so garbage on serial line is skipped
+*/
+package BME280golib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"time"
+)
+
+const (
+	REMOTE_FRAMESTART    byte = 0xB2
+	REMOTE_STATUS_OK     byte = 0
+	REMOTE_STATUS_ERROR  byte = 1
+	REMOTE_MAXMESSAGELEN int  = 254
+
+	REMOTE_DEFAULTTIMEOUT = time.Second //Server drops frames with bad checksum, client must time out
+)
+
+// RemoteError is error reported by remote end. Unwraps to errno so retry policies work over network
+type RemoteError struct {
+	Errno   syscall.Errno //0 if error was not errno
+	Message string
+}
+
+func (a RemoteError) Error() string {
+	return "remote: " + a.Message
+}
+
+func (a RemoteError) Unwrap() error {
+	if a.Errno == 0 {
+		return nil
+	}
+	return a.Errno
+}
+
+var errRemoteChecksum = errors.New("frame checksum error")
+
+func frameChecksum(data []byte) byte {
+	result := byte(0)
+	for _, b := range data {
+		result ^= b
+	}
+	return result
+}
+
+// readFrameStart skips until start of frame
+func readFrameStart(r *bufio.Reader) error {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b == REMOTE_FRAMESTART {
+			return nil
+		}
+	}
+}
+
+func writeRemoteRequest(w io.Writer, seq byte, t I2CTransaction) error {
+	n := t.Count
+	if t.Kind == I2CTRANSACTION_WRITE {
+		n = t.Data[0]
+	}
+	frame := []byte{REMOTE_FRAMESTART, seq, byte(t.Kind), t.Address, n, 0}
+	frame[5] = frameChecksum(frame[1:5])
+	_, err := w.Write(frame)
+	return err
+}
+
+// readRemoteRequest gives sequence number and transaction
+func readRemoteRequest(r *bufio.Reader) (byte, I2CTransaction, error) {
+	err := readFrameStart(r)
+	if err != nil {
+		return 0, I2CTransaction{}, err
+	}
+	var frame [5]byte
+	_, err = io.ReadFull(r, frame[:])
+	if err != nil {
+		return 0, I2CTransaction{}, err
+	}
+	if frameChecksum(frame[:4]) != frame[4] {
+		return 0, I2CTransaction{}, errRemoteChecksum
+	}
+	seq := frame[0]
+	result := I2CTransaction{Kind: I2CTransactionKind(frame[1]), Address: frame[2]}
+	switch result.Kind {
+	case I2CTRANSACTION_WRITE:
+		result.Data = []byte{frame[3]}
+	case I2CTRANSACTION_READ:
+		result.Count = frame[3]
+	case I2CTRANSACTION_CLOSE:
+	default:
+		return seq, result, fmt.Errorf("invalid request command 0x%02X", frame[1])
+	}
+	return seq, result, nil
+}
+
+// writeRemoteResponse writes result of executed transaction
+func writeRemoteResponse(w io.Writer, seq byte, t I2CTransaction, err error) error {
+	frame := []byte{REMOTE_FRAMESTART, seq, REMOTE_STATUS_OK, 0}
+	if err != nil {
+		frame[2] = REMOTE_STATUS_ERROR
+		var errno syscall.Errno
+		errors.As(err, &errno) //Stays 0 if not errno
+		msg := err.Error()
+		if REMOTE_MAXMESSAGELEN < len(msg) {
+			msg = msg[:REMOTE_MAXMESSAGELEN]
+		}
+		frame = append(frame, byte(errno))
+		frame = append(frame, msg...)
+	} else if t.Kind == I2CTRANSACTION_READ {
+		frame = append(frame, t.Data...)
+	}
+	frame[3] = byte(len(frame) - 4)
+	frame = append(frame, frameChecksum(frame[1:]))
+	_, errWrite := w.Write(frame)
+	return errWrite
+}
+
+// readRemoteResponse returns sequence number and data or error reported by remote
+func readRemoteResponse(r *bufio.Reader) (byte, []byte, error) {
+	err := readFrameStart(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var header [3]byte //seq, status, length
+	_, err = io.ReadFull(r, header[:])
+	if err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, int(header[2])+1) //With checksum
+	_, err = io.ReadFull(r, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if frameChecksum(header[:])^frameChecksum(body[:len(body)-1]) != body[len(body)-1] {
+		return 0, nil, errRemoteChecksum
+	}
+	seq, data := header[0], body[:len(body)-1]
+	switch header[1] {
+	case REMOTE_STATUS_OK:
+		return seq, data, nil
+	case REMOTE_STATUS_ERROR:
+		if len(data) == 0 {
+			return seq, nil, RemoteError{Message: "unknown error"}
+		}
+		return seq, nil, RemoteError{Errno: syscall.Errno(data[0]), Message: string(data[1:])}
+	}
+	return seq, nil, fmt.Errorf("invalid response status 0x%02X", header[1])
+}
+
+/*
+ServeI2CRemote serves requests from stream until client closes session or stream fails.
+Local device is not closed when client closes, same device can be served again
+*/
+func ServeI2CRemote(rw io.ReadWriter, dev I2CDeviceLayer) error {
+	r := bufio.NewReader(rw)
+	for {
+		seq, t, err := readRemoteRequest(r)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			if err == errRemoteChecksum {
+				continue //Resync on next frame. seq can not be trusted for error response, client times out
+			}
+			return err
+		}
+		switch t.Kind {
+		case I2CTRANSACTION_WRITE:
+			err = dev.WriteReg(t.Address, t.Data[0])
+		case I2CTRANSACTION_READ:
+			t.Data, err = dev.ReadRegs(t.Address, t.Count)
+		case I2CTRANSACTION_CLOSE:
+			return writeRemoteResponse(rw, seq, t, nil)
+		}
+		errWrite := writeRemoteResponse(rw, seq, t, err)
+		if errWrite != nil {
+			return errWrite
+		}
+	}
+}
+
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// I2CRemote implements I2CDeviceLayer by talking to ServeI2CRemote over stream
+type I2CRemote struct {
+	conn    io.ReadWriteCloser
+	r       *bufio.Reader
+	seq     byte
+	Timeout time.Duration //Per transaction, used if connection supports deadlines. 0=no timeout, blocks forever if frame is lost
+}
+
+func CreateI2CRemote(conn io.ReadWriteCloser) I2CRemote {
+	return I2CRemote{conn: conn, r: bufio.NewReader(conn), Timeout: REMOTE_DEFAULTTIMEOUT}
+}
+
+func (p *I2CRemote) transaction(t I2CTransaction) ([]byte, error) {
+	if d, ok := p.conn.(deadliner); ok && 0 < p.Timeout {
+		d.SetDeadline(time.Now().Add(p.Timeout))
+	}
+	p.seq++
+	err := writeRemoteRequest(p.conn, p.seq, t)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		seq, data, err := readRemoteResponse(p.r)
+		var remoteErr RemoteError
+		if err != nil && !errors.As(err, &remoteErr) {
+			return nil, err
+		}
+		if seq == p.seq {
+			return data, err
+		}
+		//Late response of earlier request that timed out, skip
+	}
+}
+
+func (p *I2CRemote) WriteReg(address byte, value byte) error {
+	_, err := p.transaction(I2CTransaction{Kind: I2CTRANSACTION_WRITE, Address: address, Data: []byte{value}})
+	return err
+}
+
+func (p *I2CRemote) ReadRegs(address byte, count byte) ([]byte, error) {
+	result := make([]byte, count)
+	return result, p.ReadRegsInto(address, result)
+}
+
+func (p *I2CRemote) ReadRegsInto(address byte, buf []byte) error {
+	result, err := p.transaction(I2CTransaction{Kind: I2CTRANSACTION_READ, Address: address, Count: byte(len(buf))})
+	if err != nil {
+		return err
+	}
+	if len(result) != len(buf) {
+		return fmt.Errorf("remote gave %v bytes, requested %v", len(result), len(buf))
+	}
+	copy(buf, result)
+	return nil
+}
+
+// Close ends session and closes connection
+func (p *I2CRemote) Close() error {
+	_, err := p.transaction(I2CTransaction{Kind: I2CTRANSACTION_CLOSE})
+	errClose := p.conn.Close()
+	if err != nil {
+		return err
+	}
+	return errClose
+}
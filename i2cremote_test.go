@@ -0,0 +1,65 @@
+package BME280golib
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// checkRemoteSensor runs sensor over remote bus and checks datasheet example values
+func checkRemoteSensor(t *testing.T, bus I2CDeviceLayer) {
+	t.Helper()
+	dev, err := CreateBME280I2C(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dev.Configure(BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_1, Oversample_temperature: OVRSAMPLE_1, Mode: MODE_NORMAL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	meas, err := dev.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meas.Temperature < 25.07 || 25.09 < meas.Temperature || meas.Pressure < 100653 || 100654 < meas.Pressure {
+		t.Errorf("unexpected measurement %v", meas)
+	}
+	err = dev.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemotePipe(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	fake := newFakeBME280()
+	served := make(chan error, 1)
+	go func() {
+		served <- ServeI2CRemote(serverConn, fake)
+	}()
+	client := CreateI2CRemote(clientConn)
+	client.Timeout = time.Second
+	checkRemoteSensor(t, &client)
+	if err := <-served; err != nil {
+		t.Error(err)
+	}
+	if fake.regs[REGISTER_CTRL_MEAS] != 0x27 {
+		t.Errorf("ctrl_meas 0x%02X not written over remote", fake.regs[REGISTER_CTRL_MEAS])
+	}
+}
+
+// Remote errors keep errno so retry policies work over network
+func TestRemoteErrno(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	fake := newFakeBME280()
+	fake.failReads[REGISTER_DATA] = []error{ERRNO_EREMOTEIO}
+	go ServeI2CRemote(serverConn, fake)
+	client := CreateI2CRemote(clientConn)
+	client.Timeout = time.Second
+	var buf [8]byte
+	err := client.ReadRegsInto(REGISTER_DATA, buf[:])
+	if !RetryOnErrnos(ERRNO_EREMOTEIO)(err) {
+		t.Errorf("remote error %v does not unwrap to EREMOTEIO", err)
+	}
+	client.Close()
+}
@@ -0,0 +1,75 @@
+//go:build !tinygo
+
+/*
+TCP transport for remote I2CDeviceLayer
+*/
+package BME280golib
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+/*
+lockedI2C serializes access when same device is served to many clients.
+Lock is per register operation, so register sequences (like Configure) of two clients can interleave
+*/
+type lockedI2C struct {
+	dev I2CDeviceLayer
+	mu  *sync.Mutex
+}
+
+func (p lockedI2C) WriteReg(address byte, value byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dev.WriteReg(address, value)
+}
+
+func (p lockedI2C) ReadRegs(address byte, count byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dev.ReadRegs(address, count)
+}
+
+func (p lockedI2C) ReadRegsInto(address byte, buf []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dev.ReadRegsInto(address, buf)
+}
+
+func (p lockedI2C) Close() error {
+	return nil
+}
+
+/*
+ServeI2CRemoteListener accepts connections and serves local device to each of those.
+Each register operation is atomic, but operations of clients interleave. Only one client should configure
+device, others should only read. Returns when listener fails (is closed)
+*/
+func ServeI2CRemoteListener(l net.Listener, dev I2CDeviceLayer) error {
+	locked := lockedI2C{dev: dev, mu: &sync.Mutex{}}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			ServeI2CRemote(conn, locked)
+		}()
+	}
+}
+
+// DialI2CRemote connects to ServeI2CRemoteListener over TCP. timeout is for dial and for each transaction (0=REMOTE_DEFAULTTIMEOUT)
+func DialI2CRemote(address string, timeout time.Duration) (I2CRemote, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return I2CRemote{}, err
+	}
+	result := CreateI2CRemote(conn)
+	if 0 < timeout {
+		result.Timeout = timeout
+	}
+	return result, nil
+}
@@ -0,0 +1,116 @@
+//go:build !tinygo
+
+package BME280golib
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// slowI2C delays first reads longer than client timeout
+type slowI2C struct {
+	I2CDeviceLayer
+	delay     time.Duration
+	slowReads int
+}
+
+func (p *slowI2C) ReadRegs(address byte, count byte) ([]byte, error) {
+	if 0 < p.slowReads {
+		p.slowReads--
+		time.Sleep(p.delay)
+	}
+	return p.I2CDeviceLayer.ReadRegs(address, count)
+}
+
+func TestRemoteTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("no loopback", err)
+	}
+	defer l.Close()
+	go ServeI2CRemoteListener(l, newFakeBME280())
+	client, err := DialI2CRemote(l.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRemoteSensor(t, &client)
+}
+
+// Response arriving after timeout must not be taken as response of next request. TCP because net.Pipe have no buffering
+func TestRemoteLateResponse(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("no loopback", err)
+	}
+	defer l.Close()
+	fake := newFakeBME280()
+	slow := &slowI2C{I2CDeviceLayer: fake, delay: 100 * time.Millisecond, slowReads: 1}
+	go ServeI2CRemoteListener(l, slow)
+	client, err := DialI2CRemote(l.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Timeout = 30 * time.Millisecond
+
+	_, err = client.ReadRegs(REGISTER_ID, 1)
+	if err == nil {
+		t.Fatal("slow read did not time out")
+	}
+	time.Sleep(slow.delay) //Late response is on the way
+	client.Timeout = time.Second
+	got, err := client.ReadRegs(REGISTER_CALIB_H1, 1) //Same length as timed out read
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, fake.regs[REGISTER_CALIB_H1:REGISTER_CALIB_H1+1]) {
+		t.Errorf("got %X, late response of earlier request was taken", got)
+	}
+	client.Close()
+}
+
+// corruptingConn corrupts checksum of first written frame
+type corruptingConn struct {
+	net.Conn
+	corrupted bool
+}
+
+func (p *corruptingConn) Write(b []byte) (int, error) {
+	if !p.corrupted {
+		p.corrupted = true
+		b = append([]byte{}, b...)
+		b[len(b)-1] ^= 0xFF
+	}
+	return p.Conn.Write(b)
+}
+
+// Server drops frame with bad checksum. Client with default timeout must not block forever
+func TestRemoteDroppedFrame(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("no loopback", err)
+	}
+	defer l.Close()
+	go ServeI2CRemoteListener(l, newFakeBME280())
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := CreateI2CRemote(&corruptingConn{Conn: conn})
+	if client.Timeout != REMOTE_DEFAULTTIMEOUT {
+		t.Errorf("default timeout %v", client.Timeout)
+	}
+	client.Timeout = 50 * time.Millisecond
+	_, err = client.ReadRegs(REGISTER_ID, 1)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("dropped frame gave %v, expected timeout", err)
+	}
+	got, err := client.ReadRegs(REGISTER_ID, 1)
+	if err != nil || got[0] != ID_EXPECTED {
+		t.Errorf("read after dropped frame gave %X %v", got, err)
+	}
+	client.Close()
+}
@@ -0,0 +1,175 @@
+/*
+Retry and recovery wrapper for any I2CDeviceLayer.
+Long cables and glitches on supply cause occasional EREMOTEIO/ENXIO errors. Those are usually gone on next try
+*/
+package BME280golib
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// ERRNO_EREMOTEIO is what linux i2c-dev gives on NACK. Not defined on syscall package on all platforms
+const ERRNO_EREMOTEIO syscall.Errno = 121
+
+// ErrDeviceReset is returned (wrapped) when read succeeded only after retries and device was found reset.
+// Configuration is re-applied (or OnReset called), but data read is from reset device and must be dropped
+var ErrDeviceReset = errors.New("device was reset during read")
+
+// DefaultRetryErrnos are errors that are worth of retrying on I2C bus
+var DefaultRetryErrnos = []syscall.Errno{ERRNO_EREMOTEIO, syscall.ENXIO, syscall.EIO, syscall.EAGAIN, syscall.ETIMEDOUT}
+
+// RetryOnErrnos creates retry policy function. Retries only if error is (or wraps) one of listed errnos
+func RetryOnErrnos(errnos ...syscall.Errno) func(err error) bool {
+	return func(err error) bool {
+		var errno syscall.Errno
+		if !errors.As(err, &errno) {
+			return false
+		}
+		for _, e := range errnos {
+			if e == errno {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// index of control registers on shadow
+const (
+	shadowCtrlHum  = 0
+	shadowCtrlMeas = 1
+	shadowConfig   = 2
+)
+
+var shadowRegisters = [3]byte{REGISTER_CTRL_HUM, REGISTER_CTRL_MEAS, REGISTER_CONFIG}
+
+/*
+I2CRetry wraps I2CDeviceLayer and retries failed operations.
+Keeps shadow copy of written control registers. After operation is recovered by retrying,
+ctrl_meas is read back. If it is not what was written, device have been reset (brownout etc..) and
+configuration is re-applied
+*/
+type I2CRetry struct {
+	dev         I2CDeviceLayer
+	Retries     int                            //How many times operation is retried after first failure
+	Backoff     time.Duration                  //Wait before first retry, doubled after each retry
+	MaxBackoff  time.Duration                  //Limit for backoff, 0=no limit
+	ShouldRetry func(err error) bool           //Retry policy, nil=retry all errors
+	OnReset     func(dev I2CDeviceLayer) error //Called with wrapped layer when reset is detected. nil=rewrite shadowed control registers
+
+	shadow      [3]byte
+	shadowValid [3]bool
+}
+
+// CreateI2CRetry with DefaultRetryErrnos policy
+func CreateI2CRetry(dev I2CDeviceLayer, retries int, backoff time.Duration) I2CRetry {
+	return I2CRetry{
+		dev:         dev,
+		Retries:     retries,
+		Backoff:     backoff,
+		ShouldRetry: RetryOnErrnos(DefaultRetryErrnos...),
+	}
+}
+
+// do runs operation with retries. Returns how many retries were needed
+func (p *I2CRetry) do(op func() error) (int, error) {
+	wait := p.Backoff
+	var err error
+	for attempt := 0; attempt <= p.Retries; attempt++ {
+		if 0 < attempt {
+			time.Sleep(wait)
+			wait *= 2
+			if 0 < p.MaxBackoff && p.MaxBackoff < wait {
+				wait = p.MaxBackoff
+			}
+		}
+		err = op()
+		if err == nil {
+			return attempt, nil
+		}
+		if p.ShouldRetry != nil && !p.ShouldRetry(err) {
+			return attempt, err
+		}
+	}
+	return p.Retries, fmt.Errorf("failed after %v retries %w", p.Retries, err)
+}
+
+func (p *I2CRetry) WriteReg(address byte, value byte) error {
+	retries, err := p.do(func() error { return p.dev.WriteReg(address, value) })
+	if err != nil {
+		return err
+	}
+	for i, reg := range shadowRegisters {
+		if reg == address {
+			p.shadow[i], p.shadowValid[i] = value, true
+		}
+	}
+	if address == REGISTER_RESET { //Intentional reset, configuration is going to be re-written
+		p.shadowValid = [3]bool{}
+	}
+	if 0 < retries {
+		_, err = p.recover()
+		return err
+	}
+	return nil
+}
+
+func (p *I2CRetry) ReadRegs(address byte, count byte) ([]byte, error) {
+	result := make([]byte, count)
+	return result, p.ReadRegsInto(address, result)
+}
+
+func (p *I2CRetry) ReadRegsInto(address byte, buf []byte) error {
+	retries, err := p.do(func() error { return p.dev.ReadRegsInto(address, buf) })
+	if err != nil {
+		return err
+	}
+	if 0 < retries {
+		reset, err := p.recover()
+		if err == nil && reset {
+			return fmt.Errorf("read 0x%02X: %w", address, ErrDeviceReset)
+		}
+		return err
+	}
+	return nil
+}
+
+func (p *I2CRetry) Close() error {
+	return p.dev.Close()
+}
+
+// recover checks after glitch that device still have configuration. Tells was device reset
+func (p *I2CRetry) recover() (bool, error) {
+	if !p.shadowValid[shadowCtrlMeas] {
+		return false, nil //Not configured yet, nothing to lose
+	}
+	arr, err := p.dev.ReadRegs(REGISTER_CTRL_MEAS, 1)
+	if err != nil {
+		return false, fmt.Errorf("reset check read failed %w", err)
+	}
+	expected := DecodeCtrlMeasRegister(p.shadow[shadowCtrlMeas])
+	got := DecodeCtrlMeasRegister(arr[0])
+	if expected.Mode != MODE_NORMAL { //Forced mode returns to sleep after measurement
+		expected.Mode = MODE_SLEEP
+		got.Mode = MODE_SLEEP
+	}
+	if expected == got {
+		return false, nil
+	}
+	if p.OnReset != nil {
+		return true, p.OnReset(p.dev)
+	}
+	//ctrl_hum is effective only after writing ctrl_meas
+	for _, i := range []int{shadowCtrlHum, shadowConfig, shadowCtrlMeas} {
+		if !p.shadowValid[i] {
+			continue
+		}
+		if err := p.dev.WriteReg(shadowRegisters[i], p.shadow[i]); err != nil {
+			return true, fmt.Errorf("re-applying configuration after reset failed %w", err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,94 @@
+package BME280golib
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func createRetryDevice(t *testing.T, bus *fakeBME280, retry *I2CRetry) BME280I2C {
+	t.Helper()
+	dev, err := CreateBME280I2C(retry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dev.Configure(BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_1, Oversample_temperature: OVRSAMPLE_1, Mode: MODE_NORMAL, Standby: STANDBYDURATION_62_5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dev
+}
+
+// Chip resets during failing read. Data read on retry is from reset chip and must not be taken as measurement
+func TestRetryResetMidRead(t *testing.T) {
+	bus := newFakeBME280()
+	retry := CreateI2CRetry(bus, 2, time.Millisecond)
+	dev := createRetryDevice(t, bus, &retry)
+	configured := [3]byte{bus.regs[REGISTER_CTRL_HUM], bus.regs[REGISTER_CTRL_MEAS], bus.regs[REGISTER_CONFIG]}
+
+	bus.resetOnFailure = true
+	bus.failReads[REGISTER_DATA] = []error{ERRNO_EREMOTEIO}
+	_, err := dev.Read()
+	if !errors.Is(err, ErrDeviceReset) {
+		t.Fatalf("read after reset gave %v, expected ErrDeviceReset", err)
+	}
+	if got := [3]byte{bus.regs[REGISTER_CTRL_HUM], bus.regs[REGISTER_CTRL_MEAS], bus.regs[REGISTER_CONFIG]}; got != configured {
+		t.Errorf("configuration %X not re-applied, expected %X", got, configured)
+	}
+}
+
+func TestRetryResetOnResetHook(t *testing.T) {
+	bus := newFakeBME280()
+	retry := CreateI2CRetry(bus, 2, time.Millisecond)
+	hookCalls := 0
+	retry.OnReset = func(dev I2CDeviceLayer) error {
+		hookCalls++
+		return nil
+	}
+	dev := createRetryDevice(t, bus, &retry)
+
+	bus.resetOnFailure = true
+	bus.failReads[REGISTER_DATA] = []error{ERRNO_EREMOTEIO}
+	_, err := dev.Read()
+	if !errors.Is(err, ErrDeviceReset) {
+		t.Errorf("read after reset gave %v, expected ErrDeviceReset", err)
+	}
+	if hookCalls != 1 {
+		t.Errorf("OnReset called %v times", hookCalls)
+	}
+	if bus.regs[REGISTER_CTRL_MEAS] != 0 {
+		t.Errorf("configuration re-applied 0x%02X, OnReset hook should do it", bus.regs[REGISTER_CTRL_MEAS])
+	}
+}
+
+// Glitch without reset is recovered silently
+func TestRetryRecoversGlitch(t *testing.T) {
+	bus := newFakeBME280()
+	retry := CreateI2CRetry(bus, 2, time.Millisecond)
+	dev := createRetryDevice(t, bus, &retry)
+	bus.failReads[REGISTER_DATA] = []error{ERRNO_EREMOTEIO, syscall.EIO}
+	meas, err := dev.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meas.Temperature < 25.07 || 25.09 < meas.Temperature {
+		t.Errorf("unexpected measurement %v", meas)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	bus := newFakeBME280()
+	retry := CreateI2CRetry(bus, 2, time.Millisecond)
+	dev := createRetryDevice(t, bus, &retry)
+	bus.failReads[REGISTER_DATA] = []error{syscall.EINVAL, nil}
+	_, err := dev.Read()
+	if !errors.Is(err, syscall.EINVAL) || bus.reads[REGISTER_DATA] != 1 {
+		t.Errorf("not retryable error gave %v after %v reads", err, bus.reads[REGISTER_DATA])
+	}
+	bus.failReads[REGISTER_DATA] = []error{ERRNO_EREMOTEIO, ERRNO_EREMOTEIO, ERRNO_EREMOTEIO}
+	_, err = dev.Read()
+	if !errors.Is(err, ERRNO_EREMOTEIO) {
+		t.Errorf("expected EREMOTEIO after retries, got %v", err)
+	}
+}
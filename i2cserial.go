@@ -0,0 +1,71 @@
+//go:build linux && !tinygo
+
+/*
+Host side of serial line bridge (see usbi2cbridge). Serial port is set to raw mode with termios
+*/
+package BME280golib
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// serialCBAUD is baud rate mask on c_cflag, not defined on syscall package
+const serialCBAUD = 0x100F
+
+var serialBauds = map[int]uint32{
+	9600:   syscall.B9600,
+	19200:  syscall.B19200,
+	38400:  syscall.B38400,
+	57600:  syscall.B57600,
+	115200: syscall.B115200,
+	230400: syscall.B230400,
+	460800: syscall.B460800,
+	921600: syscall.B921600,
+}
+
+// SetSerialRaw sets terminal to raw 8N1 mode. Works also on pseudo-terminal
+func SetSerialRaw(f *os.File, baud int) error {
+	speed, haz := serialBauds[baud]
+	if !haz {
+		return fmt.Errorf("unsupported baud rate %v", baud)
+	}
+	var t syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&t)))
+	if errno != 0 {
+		return fmt.Errorf("get terminal attributes %w", errno)
+	}
+	t.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	t.Oflag &^= syscall.OPOST
+	t.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	t.Cflag &^= syscall.CSIZE | syscall.PARENB | serialCBAUD
+	t.Cflag |= syscall.CS8 | syscall.CREAD | syscall.CLOCAL | speed
+	t.Ispeed = speed
+	t.Ospeed = speed
+	t.Cc[syscall.VMIN] = 1
+	t.Cc[syscall.VTIME] = 0
+	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(&t)))
+	if errno != 0 {
+		return fmt.Errorf("set terminal attributes %w", errno)
+	}
+	return nil
+}
+
+// OpenI2CSerial opens serial port where usbi2cbridge firmware is running
+func OpenI2CSerial(deviceName string, baud int) (I2CRemote, error) {
+	f, err := os.OpenFile(deviceName, os.O_RDWR|syscall.O_NOCTTY, 0600)
+	if err != nil {
+		return I2CRemote{}, err
+	}
+	err = SetSerialRaw(f, baud)
+	if err != nil {
+		f.Close()
+		return I2CRemote{}, err
+	}
+	result := CreateI2CRemote(f)
+	result.Timeout = time.Second
+	return result, nil
+}
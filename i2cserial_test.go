@@ -0,0 +1,98 @@
+//go:build linux && !tinygo
+
+package BME280golib
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// openPty gives master side and name of slave side of new pseudo-terminal
+func openPty() (*os.File, string, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	unlock := int32(0)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock)))
+	if errno != 0 {
+		master.Close()
+		return nil, "", errno
+	}
+	var n uint32
+	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n)))
+	if errno != 0 {
+		master.Close()
+		return nil, "", errno
+	}
+	return master, fmt.Sprintf("/dev/pts/%v", n), nil
+}
+
+// Bridge firmware is emulated by serving emulated chip on master side of pty
+func TestSerialPty(t *testing.T) {
+	master, slaveName, err := openPty()
+	if err != nil {
+		t.Skip("no pseudo-terminal", err)
+	}
+	defer master.Close()
+	fake := newFakeBME280()
+	served := make(chan error, 1)
+	go func() {
+		served <- ServeI2CRemote(master, fake)
+	}()
+
+	bus, err := OpenI2CSerial(slaveName, 115200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRemoteSensor(t, &bus) //Closes session
+	if err := <-served; err != nil {
+		t.Error(err)
+	}
+	if fake.regs[REGISTER_CTRL_MEAS] != 0x27 {
+		t.Errorf("ctrl_meas 0x%02X not written over serial", fake.regs[REGISTER_CTRL_MEAS])
+	}
+}
+
+// Raw mode must pass all byte values, like CR, ^C and XON/XOFF
+func TestSerialRawBytes(t *testing.T) {
+	master, slaveName, err := openPty()
+	if err != nil {
+		t.Skip("no pseudo-terminal", err)
+	}
+	defer master.Close()
+	fake := newFakeBME280()
+	for i := 0; i < 128; i++ {
+		fake.regs[i] = byte(i * 2)
+	}
+	go ServeI2CRemote(master, fake)
+
+	bus, err := OpenI2CSerial(slaveName, 115200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bus.Close()
+	got, err := bus.ReadRegs(0, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, fake.regs[:128]) {
+		t.Errorf("bytes changed on serial line\ngot      %X\nexpected %X", got, fake.regs[:128])
+	}
+}
+
+func TestSerialUnsupportedBaud(t *testing.T) {
+	master, slaveName, err := openPty()
+	if err != nil {
+		t.Skip("no pseudo-terminal", err)
+	}
+	defer master.Close()
+	_, err = OpenI2CSerial(slaveName, 12345)
+	if err == nil {
+		t.Error("unsupported baud rate accepted")
+	}
+}
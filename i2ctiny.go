@@ -7,6 +7,7 @@ import "tinygo.org/x/drivers"
 type I2CTiny struct {
 	dev        drivers.I2C
 	deviceAddr uint16
+	addrBuf    [1]byte //Register address write without allocation
 }
 
 func CreateI2CTiny(dev drivers.I2C, deviceAddr uint16) I2CTiny {
@@ -19,8 +20,12 @@ func (p *I2CTiny) WriteReg(address byte, value byte) error {
 
 func (p *I2CTiny) ReadRegs(address byte, count byte) ([]byte, error) {
 	output := make([]byte, count)
-	txErr := p.dev.Tx(p.deviceAddr, []byte{address}, output)
-	return output, txErr
+	return output, p.ReadRegsInto(address, output)
+}
+
+func (p *I2CTiny) ReadRegsInto(address byte, buf []byte) error {
+	p.addrBuf[0] = address
+	return p.dev.Tx(p.deviceAddr, p.addrBuf[:], buf)
 }
 
 func (p *I2CTiny) Close() error {
@@ -0,0 +1,155 @@
+/*
+Tracing layer for debugging bus problems without logic analyser.
+Every register access is logged with duration and decoded meaning
+*/
+package BME280golib
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// RegisterName gives datasheet name of register
+func RegisterName(address byte) string {
+	switch {
+	case address == REGISTER_ID:
+		return "id"
+	case address == REGISTER_RESET:
+		return "reset"
+	case address == REGISTER_CTRL_HUM:
+		return "ctrl_hum"
+	case address == REGISTER_STATUS:
+		return "status"
+	case address == REGISTER_CTRL_MEAS:
+		return "ctrl_meas"
+	case address == REGISTER_CONFIG:
+		return "config"
+	case REGISTER_DATA <= address && address <= 0xFE:
+		return []string{"press_msb", "press_lsb", "press_xlsb", "temp_msb", "temp_lsb", "temp_xlsb", "hum_msb", "hum_lsb"}[address-REGISTER_DATA]
+	case REGISTER_CALIB00 <= address && address <= 0xA1:
+		return fmt.Sprintf("calib%02d", address-REGISTER_CALIB00)
+	case REGISTER_CALIB26 <= address && address <= 0xF0:
+		return fmt.Sprintf("calib%02d", 26+address-REGISTER_CALIB26)
+	}
+	return fmt.Sprintf("0x%02X", address)
+}
+
+// DescribeRegValue decodes fields of single register value, like "osrs_t=1x osrs_p=16x mode=normal"
+func DescribeRegValue(address byte, value byte) string {
+	switch address {
+	case REGISTER_ID:
+		if value == ID_EXPECTED {
+			return fmt.Sprintf("chip_id=0x%02X (BME280)", value)
+		}
+		return fmt.Sprintf("chip_id=0x%02X (unexpected)", value)
+	case REGISTER_RESET:
+		if value == 0xB6 {
+			return "soft reset"
+		}
+		return fmt.Sprintf("0x%02X (no effect)", value)
+	case REGISTER_CTRL_HUM:
+		reg := DecodeCtrlHumRegister(value)
+		return fmt.Sprintf("osrs_h=%s", reg.Osrs_h)
+	case REGISTER_STATUS:
+		reg := DecodeStatusRegister(value)
+		return fmt.Sprintf("measuring=%v im_update=%v", reg.Measuring, reg.Im_update)
+	case REGISTER_CTRL_MEAS:
+		reg := DecodeCtrlMeasRegister(value)
+		return fmt.Sprintf("osrs_t=%s osrs_p=%s mode=%s", reg.Osrs_t, reg.Osrs_p, reg.Mode)
+	case REGISTER_CONFIG:
+		reg := DecodeConfigRegister(value)
+		return fmt.Sprintf("t_sb=%s filter=%s spi3w_en=%v", reg.T_sb, reg.Filter, reg.Spi3w_en)
+	}
+	return fmt.Sprintf("0x%02X", value)
+}
+
+// DescribeRegRead decodes result of burst read starting from address
+func DescribeRegRead(address byte, data []byte) string {
+	if address == REGISTER_DATA && len(data) == 8 {
+		raw := ParseRawMeas(data)
+		return fmt.Sprintf("raw press=%v temp=%v hum=%v", raw.Pressure, raw.Temperature, raw.Humidity)
+	}
+	if (address == REGISTER_CALIB00 || address == REGISTER_CALIB26) && 1 < len(data) {
+		return fmt.Sprintf("%s..%s calibration % X", RegisterName(address), RegisterName(address+byte(len(data)-1)), data)
+	}
+	parts := make([]string, len(data))
+	for i, value := range data {
+		reg := address + byte(i)
+		parts[i] = fmt.Sprintf("%s=[%s]", RegisterName(reg), DescribeRegValue(reg, value))
+	}
+	return strings.Join(parts, " ")
+}
+
+/*
+I2CTrace wraps I2CDeviceLayer and logs every transaction with log/slog.
+Log level is configurable so tracing can be left on production code and enabled from handler
+*/
+type I2CTrace struct {
+	dev    I2CDeviceLayer
+	logger *slog.Logger
+	Level  slog.Level //Level of transaction log lines, default debug
+}
+
+func CreateI2CTrace(dev I2CDeviceLayer, logger *slog.Logger) I2CTrace {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return I2CTrace{dev: dev, logger: logger, Level: slog.LevelDebug}
+}
+
+func (p *I2CTrace) WriteReg(address byte, value byte) error {
+	tStart := time.Now()
+	err := p.dev.WriteReg(address, value)
+	if !p.logger.Enabled(context.Background(), p.Level) {
+		return err
+	}
+	attrs := []slog.Attr{
+		slog.String("reg", RegisterName(address)),
+		slog.String("addr", fmt.Sprintf("0x%02X", address)),
+		slog.String("value", fmt.Sprintf("0x%02X", value)),
+		slog.Duration("dur", time.Since(tStart)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("err", err.Error()))
+	}
+	p.logger.LogAttrs(context.Background(), p.Level,
+		fmt.Sprintf("%s <- %s", RegisterName(address), DescribeRegValue(address, value)), attrs...)
+	return err
+}
+
+func (p *I2CTrace) ReadRegs(address byte, count byte) ([]byte, error) {
+	result := make([]byte, count)
+	return result, p.ReadRegsInto(address, result)
+}
+
+func (p *I2CTrace) ReadRegsInto(address byte, buf []byte) error {
+	tStart := time.Now()
+	err := p.dev.ReadRegsInto(address, buf)
+	if !p.logger.Enabled(context.Background(), p.Level) {
+		return err
+	}
+	attrs := []slog.Attr{
+		slog.String("reg", RegisterName(address)),
+		slog.String("addr", fmt.Sprintf("0x%02X", address)),
+		slog.Int("count", len(buf)),
+		slog.Duration("dur", time.Since(tStart)),
+	}
+	msg := fmt.Sprintf("%s -> read failed", RegisterName(address))
+	if err != nil {
+		attrs = append(attrs, slog.String("err", err.Error()))
+	} else {
+		attrs = append(attrs, slog.String("data", fmt.Sprintf("% X", buf)))
+		msg = fmt.Sprintf("%s -> %s", RegisterName(address), DescribeRegRead(address, buf))
+	}
+	p.logger.LogAttrs(context.Background(), p.Level, msg, attrs...)
+	return err
+}
+
+func (p *I2CTrace) Close() error {
+	err := p.dev.Close()
+	p.logger.Log(context.Background(), p.Level, "close", "err", err)
+	return err
+}
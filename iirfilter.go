@@ -0,0 +1,133 @@
+/*
+IIR filter step response and software model of chip's filter.
+Chip filters pressure and temperature, humidity is not filtered
+
+	filtered = (previous * (c-1) + new) / c
+
+Step response table is figure 7 on datasheet
+*/
+package BME280golib
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// StepSamples is how many samples are needed to reach fraction (like 0.75) of step
+func (a FilterSetting) StepSamples(fraction float64) int {
+	c := a.Coefficient()
+	if c <= 1 {
+		return 1
+	}
+	return int(math.Ceil(math.Log(1-fraction) / math.Log(1-1/float64(c))))
+}
+
+/*
+Bandwidth is -3dB bandwidth (Hz) of filter when sampling at odr (Hz). Without filter this is Nyquist frequency.
+Datasheet does not define its bandwidth figures. Use case figures (0.53Hz and 1.75Hz on presets) are about
+two times this, they match two-sided width (-f..f) of -3dB band within 4%
+*/
+func (a FilterSetting) Bandwidth(odr float64) float64 {
+	c := a.Coefficient()
+	if c <= 1 {
+		return odr / 2
+	}
+	alpha := 1 / float64(c)
+	return odr / (2 * math.Pi) * math.Acos(1-alpha*alpha/(2*(1-alpha)))
+}
+
+// FilterStepResponse tells how fast filtered output follows step change
+type FilterStepResponse struct {
+	Samples75 int
+	Samples90 int
+	Samples99 int
+	Settle75  time.Duration //Settling times at sampling rate
+	Settle90  time.Duration
+	Settle99  time.Duration
+	Bandwidth float64 //-3dB Hz
+}
+
+/*
+FilterStepResponse at sampling rate of configuration. On normal mode rate is from measurement and standby time
+and forcedInterval is ignored. On forced mode rate is set by triggering, forcedInterval is time between triggers
+*/
+func (p *BME280Config) FilterStepResponse(forcedInterval time.Duration) (FilterStepResponse, error) {
+	var period time.Duration
+	switch p.Mode {
+	case MODE_NORMAL:
+		period = time.Duration(float64(time.Second) / p.SamplingRate())
+	case MODE_FORCED:
+		if forcedInterval <= 0 {
+			return FilterStepResponse{}, fmt.Errorf("forced mode needs trigger interval for step response")
+		}
+		period = forcedInterval
+	default:
+		return FilterStepResponse{}, fmt.Errorf("no samples on %s mode", p.Mode)
+	}
+	odr := 1 / period.Seconds()
+	result := FilterStepResponse{
+		Samples75: p.Filter.StepSamples(0.75),
+		Samples90: p.Filter.StepSamples(0.90),
+		Samples99: p.Filter.StepSamples(0.99),
+		Bandwidth: p.Filter.Bandwidth(odr),
+	}
+	result.Settle75 = time.Duration(result.Samples75) * period
+	result.Settle90 = time.Duration(result.Samples90) * period
+	result.Settle99 = time.Duration(result.Samples99) * period
+	return result, nil
+}
+
+/*
+IIRFilterModel is software model of chip IIR filter. For predicting filtered output from simulated input.
+Like on chip, first value after reset passes unchanged
+*/
+type IIRFilterModel struct {
+	Coefficient int
+	value       float64
+	primed      bool
+}
+
+func CreateIIRFilterModel(setting FilterSetting) IIRFilterModel {
+	return IIRFilterModel{Coefficient: setting.Coefficient()}
+}
+
+// Feed next unfiltered value, returns filtered output
+func (p *IIRFilterModel) Feed(x float64) float64 {
+	if !p.primed || p.Coefficient <= 1 {
+		p.value = x
+		p.primed = true
+		return x
+	}
+	c := float64(p.Coefficient)
+	p.value = (p.value*(c-1) + x) / c
+	return p.value
+}
+
+// Reset filter, happens on chip when filter setting is written
+func (p *IIRFilterModel) Reset() {
+	p.primed = false
+}
+
+// IIRMeasModel filters measurements like chip does. Pressure and temperature are filtered, humidity is not
+type IIRMeasModel struct {
+	temperature IIRFilterModel
+	pressure    IIRFilterModel
+}
+
+func CreateIIRMeasModel(setting FilterSetting) IIRMeasModel {
+	return IIRMeasModel{temperature: CreateIIRFilterModel(setting), pressure: CreateIIRFilterModel(setting)}
+}
+
+func (p *IIRMeasModel) Feed(meas HumTempPressureMeas) HumTempPressureMeas {
+	return HumTempPressureMeas{
+		Temperature: p.temperature.Feed(meas.Temperature),
+		Rh:          meas.Rh,
+		Pressure:    p.pressure.Feed(meas.Pressure),
+	}
+}
+
+func (p *IIRMeasModel) Reset() {
+	p.temperature.Reset()
+	p.pressure.Reset()
+}
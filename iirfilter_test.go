@@ -0,0 +1,85 @@
+package BME280golib
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// Samples to reach 75% of step, datasheet figure 7
+var figure7Samples75 = map[FilterSetting]int{FILTER_NO: 1, FILTER_2: 2, FILTER_4: 5, FILTER_8: 11, FILTER_16: 22}
+
+func TestStepSamplesFigure7(t *testing.T) {
+	for setting, expected := range figure7Samples75 {
+		got := setting.StepSamples(0.75)
+		if got != expected {
+			t.Errorf("filter %s reaches 75%% in %v samples, datasheet %v", setting, got, expected)
+		}
+	}
+}
+
+// Software model must follow step like StepSamples tells
+func TestIIRFilterModelStep(t *testing.T) {
+	for setting := FILTER_NO; setting <= FILTER_16; setting++ {
+		for _, fraction := range []float64{0.75, 0.90, 0.99} {
+			model := CreateIIRFilterModel(setting)
+			model.Feed(0) //Settled before step
+			n := setting.StepSamples(fraction)
+			var out float64
+			for i := 1; i <= n; i++ {
+				out = model.Feed(1)
+				if i < n && fraction <= out {
+					t.Errorf("filter %s reached %v after %v samples, StepSamples %v", setting, fraction, i, n)
+				}
+			}
+			if out < fraction {
+				t.Errorf("filter %s output %.3f after %v samples, expected %v", setting, out, n, fraction)
+			}
+		}
+	}
+}
+
+func TestIIRMeasModelHumidityNotFiltered(t *testing.T) {
+	model := CreateIIRMeasModel(FILTER_16)
+	model.Feed(HumTempPressureMeas{Temperature: 20, Rh: 40, Pressure: 100000})
+	got := model.Feed(HumTempPressureMeas{Temperature: 36, Rh: 60, Pressure: 101600})
+	if got.Rh != 60 || got.Temperature != 21 || got.Pressure != 100100 {
+		t.Errorf("unexpected filtered output %v", got)
+	}
+}
+
+// Datasheet use case response times and bandwidths
+func TestFilterStepResponsePresets(t *testing.T) {
+	for _, preset := range []BME280Preset{PresetIndoorNavigation(), PresetGaming()} {
+		response, err := preset.Config.FilterStepResponse(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(response.Settle75.Seconds()-preset.ResponseTime.Seconds()) > 0.15*preset.ResponseTime.Seconds() {
+			t.Errorf("%s: 75%% response %v, datasheet %v", preset.Name, response.Settle75, preset.ResponseTime)
+		}
+		datasheetDefinition := 2 * preset.Config.Filter.Bandwidth(preset.ODR)
+		if math.Abs(datasheetDefinition-preset.FilterBandwidth) > 0.04*preset.FilterBandwidth {
+			t.Errorf("%s: two-sided bandwidth %.3gHz, datasheet %.3gHz", preset.Name, datasheetDefinition, preset.FilterBandwidth)
+		}
+	}
+}
+
+func TestFilterStepResponseForced(t *testing.T) {
+	config := PresetWeatherMonitoring().Config
+	config.Filter = FILTER_4
+	_, err := config.FilterStepResponse(0)
+	if err == nil {
+		t.Error("forced mode without trigger interval accepted")
+	}
+	response, err := config.FilterStepResponse(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Settle75 != 5*time.Minute {
+		t.Errorf("75%% settling %v on one sample per minute, expected 5m", response.Settle75)
+	}
+	if math.Abs(response.Bandwidth-FILTER_4.Bandwidth(1.0/60)) > 1e-12 {
+		t.Errorf("bandwidth %v not at trigger rate", response.Bandwidth)
+	}
+}
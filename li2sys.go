@@ -15,6 +15,7 @@ import (
 type I2CSys struct {
 	f          *os.File
 	deviceAddr uint16
+	addrBuf    [1]byte //Register address write without allocation
 }
 
 func CreateI2CSys(f *os.File, deviceAddr uint16) I2CSys {
@@ -25,7 +26,7 @@ func (p *I2CSys) selectI2CSlave() error {
 	//i2c_SLAVE := 0x0703
 	_, _, errorcode := syscall.Syscall6(syscall.SYS_IOCTL, p.f.Fd(), 0x0703, uintptr(p.deviceAddr), 0, 0, 0)
 	if errorcode != 0 {
-		return fmt.Errorf("select I2C slave errcode %v", errorcode)
+		return fmt.Errorf("select I2C slave errcode %w", errorcode)
 	}
 	return nil
 }
@@ -40,18 +41,26 @@ func (p *I2CSys) WriteReg(address byte, value byte) error {
 }
 
 func (p *I2CSys) ReadRegs(address byte, count byte) ([]byte, error) {
-	selectErr := p.selectI2CSlave()
 	result := make([]byte, count)
+	return result, p.ReadRegsInto(address, result)
+}
+
+func (p *I2CSys) ReadRegsInto(address byte, buf []byte) error {
+	selectErr := p.selectI2CSlave()
 	if selectErr != nil {
-		return result, selectErr
+		return selectErr
 	}
 
-	_, err := p.f.Write([]byte{address})
+	p.addrBuf[0] = address
+	_, err := p.f.Write(p.addrBuf[:])
 	if err != nil {
-		return result, err
+		return err
+	}
+	n, err := p.f.Read(buf)
+	if err == nil && n < len(buf) {
+		return fmt.Errorf("short read %v of %v bytes", n, len(buf))
 	}
-	_, err = p.f.Read(result)
-	return result, err
+	return err
 }
 
 func (p *I2CSys) Close() error {
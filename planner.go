@@ -0,0 +1,212 @@
+/*
+Configuration planner. Picks oversampling, filter and standby settings from requirements
+like "pressure noise below 0.3 Pa, at least 10 Hz, under 50 µA"
+
+Noise model: RMS noise of single 1x sample is reduced by square root of oversampling and by
+IIR filter variance reduction 1/(2c-1). Base noise figures are datasheet figures without oversampling and filter.
+Model is approximate, real noise goes down slower than square root on high oversampling. Pressure noise of
+indoor navigation preset is 0.15Pa by model and 0.2Pa by datasheet, gaming preset 0.30Pa and 0.3Pa.
+Filter lowers noise but slows response, so response time to step is objective too.
+Only normal mode is planned, on forced mode data rate is set by trigger interval
+*/
+package BME280golib
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// RMS noise of single sample without oversampling and filtering
+const (
+	NOISE_PRESSURE_1X    float64 = 3.3   //Pa
+	NOISE_TEMPERATURE_1X float64 = 0.005 //Celsius
+	NOISE_HUMIDITY_1X    float64 = 0.07  //%RH
+)
+
+// Current consumption from datasheet, µA
+const (
+	CURRENT_SLEEP       float64 = 0.1
+	CURRENT_STANDBY     float64 = 0.2
+	CURRENT_HUMIDITY    float64 = 340 //During humidity measurement
+	CURRENT_PRESSURE    float64 = 714 //During pressure measurement
+	CURRENT_TEMPERATURE float64 = 350 //During temperature measurement
+)
+
+// ConfigEstimate is expected performance of configuration
+type ConfigEstimate struct {
+	Config           BME280Config
+	ODR              float64       //Typical output data rate Hz, on normal mode
+	ODRMin           float64       //With maximum measurement duration
+	PressureNoise    float64       //RMS Pa, 0 if skipped
+	TemperatureNoise float64       //RMS Celsius, 0 if skipped
+	HumidityNoise    float64       //RMS %RH, 0 if skipped
+	Current          float64       //Average µA on normal mode
+	ResponseTime     time.Duration //Time to 75% of step on pressure and temperature with filter
+}
+
+func (a ConfigEstimate) String() string {
+	return fmt.Sprintf("%s\n  ODR %.3gHz (min %.3gHz) current %.4gµA response %v noise: pressure %.3gPa temperature %.3gC humidity %.3g%%RH",
+		a.Config, a.ODR, a.ODRMin, a.Current, a.ResponseTime, a.PressureNoise, a.TemperatureNoise, a.HumidityNoise)
+}
+
+// filterNoiseFactor is RMS noise reduction of IIR filter
+func (a FilterSetting) filterNoiseFactor() float64 {
+	c := float64(a.Coefficient())
+	if c == 0 {
+		return 1
+	}
+	return math.Sqrt(1 / (2*c - 1))
+}
+
+func oversampledNoise(base float64, ovr Oversample) float64 {
+	n := ovr.HowManyTimes()
+	if n <= 0 {
+		return 0
+	}
+	return base / math.Sqrt(float64(n))
+}
+
+/*
+measurementCharge is typical charge of one measurement cycle (µA*ms) and typical measurement time.
+Datasheet 9.1: t_measure = 1 + 2*osrs_t + (2*osrs_p + 0.5) + (2*osrs_h + 0.5) ms
+*/
+func (p *BME280Config) measurementCharge() (float64, time.Duration) {
+	tT := 1 + 2*float64(max(p.Oversample_temperature.HowManyTimes(), 0))
+	charge := tT * CURRENT_TEMPERATURE
+	measTime := tT
+	if p.Oversample_pressure != OVRSAMPLE_NO {
+		t := 0.5 + 2*float64(p.Oversample_pressure.HowManyTimes())
+		charge += t * CURRENT_PRESSURE
+		measTime += t
+	}
+	if p.Oversample_humidity != OVRSAMPLE_NO {
+		t := 0.5 + 2*float64(p.Oversample_humidity.HowManyTimes())
+		charge += t * CURRENT_HUMIDITY
+		measTime += t
+	}
+	return charge, time.Duration(measTime * float64(time.Millisecond))
+}
+
+// Estimate performance of configuration on normal mode
+func (p *BME280Config) Estimate() ConfigEstimate {
+	standby := p.Standby.Duration()
+	result := ConfigEstimate{
+		Config:        *p,
+		ODR:           p.SamplingRate(),
+		ODRMin:        1 / (p.MeasurementDurationMaximum() + standby).Seconds(),
+		HumidityNoise: oversampledNoise(NOISE_HUMIDITY_1X, p.Oversample_humidity), //Filter is not applied on humidity
+		Current:       p.AverageCurrentNormal(),
+		ResponseTime:  time.Duration(float64(p.Filter.StepSamples(0.75)) / p.SamplingRate() * float64(time.Second)),
+	}
+	filt := p.Filter.filterNoiseFactor()
+	result.PressureNoise = oversampledNoise(NOISE_PRESSURE_1X, p.Oversample_pressure) * filt
+	result.TemperatureNoise = oversampledNoise(NOISE_TEMPERATURE_1X, p.Oversample_temperature) * filt
+	return result
+}
+
+// ConfigRequirements for planner. Zero limit means that there is no limit
+type ConfigRequirements struct {
+	Pressure    bool //Channels needed, other channels are skipped
+	Temperature bool
+	Humidity    bool
+
+	MaxPressureNoise    float64       //Pa
+	MaxTemperatureNoise float64       //Celsius
+	MaxHumidityNoise    float64       //%RH
+	MinODR              float64       //Hz
+	MaxCurrent          float64       //µA
+	MaxResponseTime     time.Duration //To 75% of step on pressure and temperature
+}
+
+func (a *ConfigRequirements) accepts(e ConfigEstimate) bool {
+	return (a.MaxPressureNoise == 0 || e.PressureNoise <= a.MaxPressureNoise) &&
+		(a.MaxTemperatureNoise == 0 || e.TemperatureNoise <= a.MaxTemperatureNoise) &&
+		(a.MaxHumidityNoise == 0 || e.HumidityNoise <= a.MaxHumidityNoise) &&
+		e.ODR >= a.MinODR &&
+		(a.MaxCurrent == 0 || e.Current <= a.MaxCurrent) &&
+		(a.MaxResponseTime == 0 || e.ResponseTime <= a.MaxResponseTime)
+}
+
+// dominates tells is a at least as good as b on every objective and better at least on one
+func dominates(a ConfigEstimate, b ConfigEstimate) bool {
+	aObj := [6]float64{a.Current, -a.ODR, a.PressureNoise, a.TemperatureNoise, a.HumidityNoise, a.ResponseTime.Seconds()}
+	bObj := [6]float64{b.Current, -b.ODR, b.PressureNoise, b.TemperatureNoise, b.HumidityNoise, b.ResponseTime.Seconds()}
+	better := false
+	for i := range aObj {
+		if bObj[i] < aObj[i] {
+			return false
+		}
+		if aObj[i] < bObj[i] {
+			better = true
+		}
+	}
+	return better
+}
+
+func channelOversamples(needed bool) []Oversample {
+	if !needed {
+		return []Oversample{OVRSAMPLE_NO}
+	}
+	return []Oversample{OVRSAMPLE_1, OVRSAMPLE_2, OVRSAMPLE_4, OVRSAMPLE_8, OVRSAMPLE_16}
+}
+
+/*
+PlanConfigs enumerates all valid normal mode configurations and returns Pareto-best ones
+(current, ODR, noise and response time) matching requirements. Sorted by current consumption, lowest first.
+Temperature is always measured when pressure or humidity is needed, compensation requires it
+*/
+func PlanConfigs(req ConfigRequirements) []ConfigEstimate {
+	if !req.Pressure && !req.Temperature && !req.Humidity {
+		return []ConfigEstimate{}
+	}
+	candidates := []ConfigEstimate{}
+	for _, ovrT := range channelOversamples(true) {
+		for _, ovrP := range channelOversamples(req.Pressure) {
+			for _, ovrH := range channelOversamples(req.Humidity) {
+				for filt := FILTER_NO; filt <= FILTER_16; filt++ {
+					if !req.Pressure && !req.Temperature && filt != FILTER_NO {
+						continue //Filter does not affect humidity
+					}
+					for sb := STANDBYDURATION_0_5; sb <= STANDBYDURATION_20; sb++ {
+						conf := BME280Config{
+							Oversample_humidity:    ovrH,
+							Oversample_pressure:    ovrP,
+							Oversample_temperature: ovrT,
+							Mode:                   MODE_NORMAL,
+							Standby:                sb,
+							Filter:                 filt,
+						}
+						e := conf.Estimate()
+						if !req.Temperature { //Temperature noise does not matter, do not prefer higher oversampling
+							e.TemperatureNoise = 0
+						}
+						if req.accepts(e) {
+							candidates = append(candidates, e)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	result := []ConfigEstimate{}
+	for i, a := range candidates {
+		dominated := false
+		for j, b := range candidates {
+			if i != j && dominates(b, a) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			if !req.Temperature {
+				a.TemperatureNoise = a.Config.Estimate().TemperatureNoise
+			}
+			result = append(result, a)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Current < result[j].Current })
+	return result
+}
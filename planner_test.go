@@ -0,0 +1,66 @@
+package BME280golib
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// Noise figures on planner.go comment
+func TestEstimatePresetNoise(t *testing.T) {
+	cases := []struct {
+		preset BME280Preset
+		model  float64
+	}{
+		{PresetWeatherMonitoring(), 3.3},
+		{PresetIndoorNavigation(), 0.15},
+		{PresetGaming(), 0.30},
+	}
+	for _, c := range cases {
+		got := c.preset.Config.Estimate().PressureNoise
+		if math.Abs(got-c.model) > 0.005 {
+			t.Errorf("%s: model pressure noise %.3gPa, expected %.3gPa (datasheet %.3gPa)", c.preset.Name, got, c.model, c.preset.PressureNoise)
+		}
+	}
+}
+
+func TestPlanConfigsRequirements(t *testing.T) {
+	req := ConfigRequirements{Pressure: true, Temperature: true, MaxPressureNoise: 0.3, MinODR: 10, MaxCurrent: 700, MaxResponseTime: 500 * time.Millisecond}
+	plans := PlanConfigs(req)
+	if len(plans) == 0 {
+		t.Fatal("no configuration found")
+	}
+	for _, e := range plans {
+		if !req.accepts(e) {
+			t.Errorf("not matching requirements %s", e)
+		}
+		if e.Config.Oversample_humidity != OVRSAMPLE_NO {
+			t.Errorf("humidity not skipped %s", e)
+		}
+	}
+	for i := 1; i < len(plans); i++ {
+		if plans[i].Current < plans[i-1].Current {
+			t.Error("not sorted by current")
+		}
+	}
+}
+
+// Filter slows response, so strongest filter does not win always
+func TestPlanConfigsFilterTradeoff(t *testing.T) {
+	filters := map[FilterSetting]bool{}
+	for _, e := range PlanConfigs(ConfigRequirements{Pressure: true, MinODR: 20, MaxPressureNoise: 1}) {
+		filters[e.Config.Filter] = true
+		samples := e.Config.Filter.StepSamples(0.75)
+		if expected := time.Duration(float64(samples) / e.ODR * float64(time.Second)); e.ResponseTime != expected {
+			t.Errorf("response time %v expected %v %s", e.ResponseTime, expected, e)
+		}
+	}
+	if len(filters) < 2 {
+		t.Errorf("only filters %v on plan", filters)
+	}
+	for _, e := range PlanConfigs(ConfigRequirements{Pressure: true, MinODR: 1, MaxResponseTime: 2 * time.Second}) {
+		if e.ResponseTime > 2*time.Second {
+			t.Errorf("response time limit ignored %s", e)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Current consumption and battery life estimation.
+Uses datasheet current figures for sleep, standby and measurement of each channel
+*/
+package BME280golib
+
+import (
+	"math"
+	"time"
+)
+
+// AverageCurrentNormal is average current on normal mode in µA
+func (p *BME280Config) AverageCurrentNormal() float64 {
+	charge, measTime := p.measurementCharge()
+	standby := p.Standby.Duration()
+	return (charge + CURRENT_STANDBY*durationMs(standby)) / durationMs(measTime+standby)
+}
+
+// AverageCurrentForced is average current in µA when forced measurement is triggered at interval, sleeping between
+func (p *BME280Config) AverageCurrentForced(interval time.Duration) float64 {
+	charge, measTime := p.measurementCharge()
+	if interval < measTime { //Can not trigger faster than measuring
+		interval = measTime
+	}
+	return (charge + CURRENT_SLEEP*durationMs(interval-measTime)) / durationMs(interval)
+}
+
+// AverageCurrent by configured mode in µA. forcedInterval is used only on forced mode
+func (p *BME280Config) AverageCurrent(forcedInterval time.Duration) float64 {
+	switch p.Mode {
+	case MODE_NORMAL:
+		return p.AverageCurrentNormal()
+	case MODE_FORCED:
+		return p.AverageCurrentForced(forcedInterval)
+	}
+	return CURRENT_SLEEP
+}
+
+// BatteryLife with average current µA from battery with capacity in mAh. Only sensor consumption is counted
+func BatteryLife(averageCurrent float64, capacity float64) time.Duration {
+	if averageCurrent <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	hours := capacity * 1000 / averageCurrent
+	if float64(math.MaxInt64) <= hours*float64(time.Hour) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(hours * float64(time.Hour))
+}
+
+// BatteryLife of configuration from battery capacity in mAh. forcedInterval is used only on forced mode
+func (p *BME280Config) BatteryLife(capacity float64, forcedInterval time.Duration) time.Duration {
+	return BatteryLife(p.AverageCurrent(forcedInterval), capacity)
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
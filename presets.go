@@ -0,0 +1,142 @@
+/*
+Recommended settings for use cases. From datasheet section 3.5
+Expected performance figures are also from datasheet
+*/
+package BME280golib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type BME280Preset struct {
+	Name            string
+	Description     string
+	Config          BME280Config
+	ForcedInterval  time.Duration //How often forced measurement is triggered, 0 on normal mode
+	ODR             float64       //Output data rate Hz
+	PressureNoise   float64       //RMS noise Pa, 0 if pressure is skipped
+	HumidityNoise   float64       //RMS noise %RH, 0 if humidity is skipped
+	Current         float64       //Average current consumption µA
+	FilterBandwidth float64       //Hz as on datasheet, 0 if filter is off. About 2x FilterSetting.Bandwidth, see there
+	ResponseTime    time.Duration //Time to 75% of step, 0 if filter is off
+}
+
+func (a BME280Preset) String() string {
+	return fmt.Sprintf("%s: %s\n%s\nODR %.4gHz, current %vµA, pressure noise %vPa, humidity noise %v%%RH",
+		a.Name, a.Description, a.Config, a.ODR, a.Current, a.PressureNoise, a.HumidityNoise)
+}
+
+// PresetWeatherMonitoring datasheet 3.5.1
+func PresetWeatherMonitoring() BME280Preset {
+	return BME280Preset{
+		Name:        "weather",
+		Description: "weather monitoring, forced mode 1 sample per minute",
+		Config: BME280Config{
+			Oversample_humidity:    OVRSAMPLE_1,
+			Oversample_pressure:    OVRSAMPLE_1,
+			Oversample_temperature: OVRSAMPLE_1,
+			Mode:                   MODE_FORCED,
+			Standby:                STANDBYDURATION_0_5,
+			Filter:                 FILTER_NO,
+		},
+		ForcedInterval: time.Minute,
+		ODR:            1.0 / 60,
+		PressureNoise:  3.3,
+		HumidityNoise:  0.07,
+		Current:        0.16,
+	}
+}
+
+// PresetHumiditySensing datasheet 3.5.2
+func PresetHumiditySensing() BME280Preset {
+	return BME280Preset{
+		Name:        "humidity",
+		Description: "humidity sensing, forced mode 1 sample per second",
+		Config: BME280Config{
+			Oversample_humidity:    OVRSAMPLE_1,
+			Oversample_pressure:    OVRSAMPLE_NO,
+			Oversample_temperature: OVRSAMPLE_1,
+			Mode:                   MODE_FORCED,
+			Standby:                STANDBYDURATION_0_5,
+			Filter:                 FILTER_NO,
+		},
+		ForcedInterval: time.Second,
+		ODR:            1,
+		HumidityNoise:  0.07,
+		Current:        2.9,
+	}
+}
+
+// PresetIndoorNavigation datasheet 3.5.3
+func PresetIndoorNavigation() BME280Preset {
+	return BME280Preset{
+		Name:        "indoornavigation",
+		Description: "indoor navigation, normal mode with high pressure resolution",
+		Config: BME280Config{
+			Oversample_humidity:    OVRSAMPLE_1,
+			Oversample_pressure:    OVRSAMPLE_16,
+			Oversample_temperature: OVRSAMPLE_2,
+			Mode:                   MODE_NORMAL,
+			Standby:                STANDBYDURATION_0_5,
+			Filter:                 FILTER_16,
+		},
+		ODR:             25,
+		PressureNoise:   0.2,
+		HumidityNoise:   0.07,
+		Current:         633,
+		FilterBandwidth: 0.53,
+		ResponseTime:    900 * time.Millisecond,
+	}
+}
+
+// PresetGaming datasheet 3.5.4
+func PresetGaming() BME280Preset {
+	return BME280Preset{
+		Name:        "gaming",
+		Description: "gaming, normal mode fast pressure response, humidity skipped",
+		Config: BME280Config{
+			Oversample_humidity:    OVRSAMPLE_NO,
+			Oversample_pressure:    OVRSAMPLE_4,
+			Oversample_temperature: OVRSAMPLE_1,
+			Mode:                   MODE_NORMAL,
+			Standby:                STANDBYDURATION_0_5,
+			Filter:                 FILTER_16,
+		},
+		ODR:             83,
+		PressureNoise:   0.3,
+		Current:         581,
+		FilterBandwidth: 1.75,
+		ResponseTime:    300 * time.Millisecond,
+	}
+}
+
+var presetConstructors = map[string]func() BME280Preset{
+	"weather":          PresetWeatherMonitoring,
+	"humidity":         PresetHumiditySensing,
+	"indoornavigation": PresetIndoorNavigation,
+	"gaming":           PresetGaming,
+}
+
+// PresetNames lists names accepted by GetPreset
+func PresetNames() []string {
+	result := make([]string, 0, len(presetConstructors))
+	for name := range presetConstructors {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// GetPreset by name, for command line tools. Case, spaces, dashes and underscores do not matter
+func GetPreset(name string) (BME280Preset, error) {
+	key := strings.ToLower(strings.NewReplacer("-", "", "_", "", " ", "").Replace(name))
+	constructor, haz := presetConstructors[key]
+	if !haz {
+		return BME280Preset{}, fmt.Errorf("unknown preset %q, use %s", name, strings.Join(PresetNames(), ", "))
+	}
+	result := constructor()
+	return result, result.Config.GotError()
+}
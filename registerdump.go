@@ -0,0 +1,257 @@
+/*
+Register dump of whole 0x88..0xFE register space with decoded fields.
+Dumps can be saved as text and compared, for finding out what is different on misbehaving unit
+*/
+package BME280golib
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	REGISTERDUMP_FIRST byte = REGISTER_CALIB00
+	REGISTERDUMP_LAST  byte = 0xFE
+	REGISTERDUMP_SIZE  int  = int(REGISTERDUMP_LAST-REGISTERDUMP_FIRST) + 1 //119 bytes
+)
+
+// RegisterDump is content of registers REGISTERDUMP_FIRST...REGISTERDUMP_LAST
+type RegisterDump [REGISTERDUMP_SIZE]byte
+
+// ReadRegisterDump reads whole register space with one burst read
+func ReadRegisterDump(dev I2CDeviceLayer) (RegisterDump, error) {
+	var result RegisterDump
+	err := dev.ReadRegsInto(REGISTERDUMP_FIRST, result[:])
+	if err != nil {
+		return result, fmt.Errorf("register dump read failed %w", err)
+	}
+	return result, nil
+}
+
+// Reg gives value of register. Address must be between REGISTERDUMP_FIRST and REGISTERDUMP_LAST
+func (p *RegisterDump) Reg(address byte) byte {
+	return p[address-REGISTERDUMP_FIRST]
+}
+
+func (p *RegisterDump) regs(address byte, count int) []byte {
+	start := int(address - REGISTERDUMP_FIRST)
+	return p[start : start+count]
+}
+
+func (p *RegisterDump) Calibration() (CalibrationRegs, error) {
+	return ParseCalibration(p.regs(REGISTER_CALIB00, 24), p.regs(REGISTER_CALIB26, 7), p.Reg(REGISTER_CALIB_H1))
+}
+
+func (p *RegisterDump) Raw() RawMeas {
+	return ParseRawMeas(p.regs(REGISTER_DATA, 8))
+}
+
+// RegisterField is decoded field of dump
+type RegisterField struct {
+	Address byte //First register where field is
+	Name    string
+	Value   string
+}
+
+func (a RegisterField) String() string {
+	return fmt.Sprintf("0x%02X %s=%s", a.Address, a.Name, a.Value)
+}
+
+type fieldCollector struct {
+	fields  []RegisterField
+	covered [REGISTERDUMP_SIZE]bool
+}
+
+func (p *fieldCollector) add(address byte, count int, name string, value string) {
+	p.fields = append(p.fields, RegisterField{Address: address, Name: name, Value: value})
+	for i := 0; i < count; i++ {
+		p.covered[int(address-REGISTERDUMP_FIRST)+i] = true
+	}
+}
+
+/*
+Fields decodes all documented fields: calibration, chip id, control, status and data.
+Compensated values are included if calibration is valid.
+Undocumented registers are listed as raw hex, so every byte of dump is on some field
+*/
+func (p *RegisterDump) Fields() []RegisterField {
+	c := fieldCollector{}
+
+	//Calibration parameters as datasheet table 16
+	calibNames := []string{"dig_T1", "dig_T2", "dig_T3", "dig_P1", "dig_P2", "dig_P3", "dig_P4", "dig_P5", "dig_P6", "dig_P7", "dig_P8", "dig_P9"}
+	for i, name := range calibNames {
+		address := REGISTER_CALIB00 + byte(2*i)
+		v := binary.LittleEndian.Uint16(p.regs(address, 2))
+		if name == "dig_T1" || name == "dig_P1" {
+			c.add(address, 2, name, fmt.Sprint(v))
+		} else {
+			c.add(address, 2, name, fmt.Sprint(int16(v)))
+		}
+	}
+	calib, errCalib := p.Calibration()
+	c.add(REGISTER_CALIB_H1, 1, "dig_H1", fmt.Sprint(calib.H1))
+	c.add(REGISTER_CALIB26, 2, "dig_H2", fmt.Sprint(calib.H2))
+	c.add(REGISTER_CALIB26+2, 1, "dig_H3", fmt.Sprint(calib.H3))
+	c.add(REGISTER_CALIB26+3, 1, "dig_H4", fmt.Sprint(calib.H4)) //0xE5 is shared by H4 and H5
+	c.add(REGISTER_CALIB26+4, 2, "dig_H5", fmt.Sprint(calib.H5))
+	c.add(REGISTER_CALIB26+6, 1, "dig_H6", fmt.Sprint(calib.H6))
+
+	c.add(REGISTER_ID, 1, "chip_id", fmt.Sprintf("0x%02X", p.Reg(REGISTER_ID)))
+	c.add(REGISTER_RESET, 1, "reset", fmt.Sprintf("0x%02X", p.Reg(REGISTER_RESET)))
+
+	hum := DecodeCtrlHumRegister(p.Reg(REGISTER_CTRL_HUM))
+	c.add(REGISTER_CTRL_HUM, 1, "osrs_h", hum.Osrs_h.String())
+	c.add(REGISTER_CTRL_HUM, 1, "ctrl_hum_reserved", fmt.Sprintf("0x%02X", hum.Reserved))
+
+	status := DecodeStatusRegister(p.Reg(REGISTER_STATUS))
+	c.add(REGISTER_STATUS, 1, "measuring", fmt.Sprint(status.Measuring))
+	c.add(REGISTER_STATUS, 1, "im_update", fmt.Sprint(status.Im_update))
+
+	meas := DecodeCtrlMeasRegister(p.Reg(REGISTER_CTRL_MEAS))
+	c.add(REGISTER_CTRL_MEAS, 1, "osrs_t", meas.Osrs_t.String())
+	c.add(REGISTER_CTRL_MEAS, 1, "osrs_p", meas.Osrs_p.String())
+	c.add(REGISTER_CTRL_MEAS, 1, "mode", meas.Mode.String())
+
+	conf := DecodeConfigRegister(p.Reg(REGISTER_CONFIG))
+	c.add(REGISTER_CONFIG, 1, "t_sb", conf.T_sb.String())
+	c.add(REGISTER_CONFIG, 1, "filter", conf.Filter.String())
+	c.add(REGISTER_CONFIG, 1, "spi3w_en", fmt.Sprint(conf.Spi3w_en))
+
+	raw := p.Raw()
+	c.add(REGISTER_DATA, 3, "press_raw", fmt.Sprint(raw.Pressure))
+	c.add(REGISTER_DATA+3, 3, "temp_raw", fmt.Sprint(raw.Temperature))
+	c.add(REGISTER_DATA+6, 2, "hum_raw", fmt.Sprint(raw.Humidity))
+	if errCalib == nil {
+		meas, errComp := raw.Compensate(calib)
+		if errComp == nil {
+			c.add(REGISTER_DATA, 0, "pressure", fmt.Sprintf("%.2fPa", meas.Pressure))
+			c.add(REGISTER_DATA+3, 0, "temperature", fmt.Sprintf("%.2fC", meas.Temperature))
+			c.add(REGISTER_DATA+6, 0, "humidity", fmt.Sprintf("%.2f%%RH", meas.Rh))
+		}
+	}
+
+	for i, haz := range c.covered {
+		if !haz {
+			address := REGISTERDUMP_FIRST + byte(i)
+			c.add(address, 1, fmt.Sprintf("reg_%02X", address), fmt.Sprintf("0x%02X", p[i]))
+		}
+	}
+	return c.fields
+}
+
+// ToTable lists decoded fields, one per line
+func (p *RegisterDump) ToTable() string {
+	var sb strings.Builder
+	for _, field := range p.Fields() {
+		sb.WriteString(fmt.Sprintf("0x%02X\t%s\t%s\n", field.Address, field.Name, field.Value))
+	}
+	return sb.String()
+}
+
+// HexDump is text format for saving dumps. 16 registers per line, lines starting with # are comments
+func (p *RegisterDump) HexDump() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# BME280 registers 0x%02X-0x%02X\n", REGISTERDUMP_FIRST, REGISTERDUMP_LAST))
+	for i := 0; i < REGISTERDUMP_SIZE; i += 16 {
+		sb.WriteString(fmt.Sprintf("0x%02X:", int(REGISTERDUMP_FIRST)+i))
+		for j := i; j < min(i+16, REGISTERDUMP_SIZE); j++ {
+			sb.WriteString(fmt.Sprintf(" %02X", p[j]))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// ParseRegisterDump parses HexDump format. Every register must be present
+func ParseRegisterDump(r io.Reader) (RegisterDump, error) {
+	var result RegisterDump
+	var got [REGISTERDUMP_SIZE]bool
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		addressPart, dataPart, found := strings.Cut(line, ":")
+		if !found {
+			return result, fmt.Errorf("line %v: missing address", lineNumber)
+		}
+		address, errAddress := strconv.ParseUint(strings.TrimSpace(addressPart), 0, 8)
+		if errAddress != nil {
+			return result, fmt.Errorf("line %v: invalid address %q", lineNumber, addressPart)
+		}
+		for _, s := range strings.Fields(dataPart) {
+			if address < uint64(REGISTERDUMP_FIRST) || uint64(REGISTERDUMP_LAST) < address {
+				return result, fmt.Errorf("line %v: register 0x%02X out of dump range", lineNumber, address)
+			}
+			v, errValue := strconv.ParseUint(s, 16, 8)
+			if errValue != nil {
+				return result, fmt.Errorf("line %v: invalid value %q", lineNumber, s)
+			}
+			result[address-uint64(REGISTERDUMP_FIRST)] = byte(v)
+			got[address-uint64(REGISTERDUMP_FIRST)] = true
+			address++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+	for i, haz := range got {
+		if !haz {
+			return result, fmt.Errorf("register 0x%02X missing from dump", int(REGISTERDUMP_FIRST)+i)
+		}
+	}
+	return result, nil
+}
+
+// RegisterFieldDiff is field that have different value on dumps
+type RegisterFieldDiff struct {
+	Address byte
+	Name    string
+	A       string
+	B       string
+}
+
+func (a RegisterFieldDiff) String() string {
+	return fmt.Sprintf("0x%02X %s: %s -> %s", a.Address, a.Name, a.A, a.B)
+}
+
+// DiffRegisterDumps lists fields that differ. Empty if dumps are identical
+func DiffRegisterDumps(a RegisterDump, b RegisterDump) []RegisterFieldDiff {
+	result := []RegisterFieldDiff{}
+	fieldsA := a.Fields()
+	fieldsB := b.Fields()
+	values := make(map[string]string, len(fieldsB))
+	for _, field := range fieldsB {
+		values[field.Name] = field.Value
+	}
+	for _, field := range fieldsA {
+		valueB, haz := values[field.Name]
+		if !haz {
+			valueB = "n/a" //Compensated value is missing if calibration is invalid
+		}
+		if field.Value != valueB {
+			result = append(result, RegisterFieldDiff{Address: field.Address, Name: field.Name, A: field.Value, B: valueB})
+		}
+	}
+	for _, field := range fieldsB {
+		found := false
+		for _, fieldA := range fieldsA {
+			if fieldA.Name == field.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, RegisterFieldDiff{Address: field.Address, Name: field.Name, A: "n/a", B: field.Value})
+		}
+	}
+	return result
+}
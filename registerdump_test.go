@@ -0,0 +1,100 @@
+package BME280golib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func fakeDump(t *testing.T, config BME280Config) RegisterDump {
+	t.Helper()
+	bus := newFakeBME280()
+	dev, err := CreateBME280I2C(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dev.Configure(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump, err := ReadRegisterDump(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dump
+}
+
+var dumpConfig = BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_16, Oversample_temperature: OVRSAMPLE_2, Mode: MODE_NORMAL, Standby: STANDBYDURATION_62_5, Filter: FILTER_16}
+
+func TestRegisterDumpRoundTrip(t *testing.T) {
+	dump := fakeDump(t, dumpConfig)
+	text := dump.HexDump()
+	parsed, err := ParseRegisterDump(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("%v\n%s", err, text)
+	}
+	if parsed != dump {
+		t.Errorf("round trip changed dump\n%s\n%s", text, parsed.HexDump())
+	}
+	if parsed.Reg(REGISTER_ID) != ID_EXPECTED {
+		t.Errorf("chip id 0x%02X", parsed.Reg(REGISTER_ID))
+	}
+}
+
+func TestRegisterDumpMalformed(t *testing.T) {
+	dump := fakeDump(t, dumpConfig)
+	valid := dump.HexDump()
+	lines := strings.Split(strings.TrimSpace(valid), "\n")
+	allButLast := strings.Join(lines[:len(lines)-1], "\n")
+
+	for _, tc := range []struct {
+		name string
+		text string
+		err  string
+	}{
+		{"missing address", valid + "00 11\n", "missing address"},
+		{"invalid address", valid + "zz: 00\n", "invalid address"},
+		{"address overflow", valid + "0x100: 00\n", "invalid address"},
+		{"below range", valid + "0x10: 00\n", "out of dump range"},
+		{"past last register", valid + "0xFE: 00 00\n", "out of dump range"},
+		{"invalid value", valid + "0x88: GG\n", "invalid value"},
+		{"value overflow", valid + "0x88: 100\n", "invalid value"},
+		{"missing registers", allButLast, "missing from dump"},
+		{"empty", "# nothing\n", "missing from dump"},
+	} {
+		_, err := ParseRegisterDump(strings.NewReader(tc.text))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: got error %v, expected %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestDiffRegisterDumps(t *testing.T) {
+	a := fakeDump(t, dumpConfig)
+	if diff := DiffRegisterDumps(a, a); len(diff) != 0 {
+		t.Errorf("identical dumps differ %v", diff)
+	}
+
+	changed := dumpConfig
+	changed.Filter = FILTER_4
+	changed.Oversample_pressure = OVRSAMPLE_2
+	b := fakeDump(t, changed)
+	expected := []RegisterFieldDiff{
+		{Address: REGISTER_CTRL_MEAS, Name: "osrs_p", A: OVRSAMPLE_16.String(), B: OVRSAMPLE_2.String()},
+		{Address: REGISTER_CONFIG, Name: "filter", A: FILTER_16.String(), B: FILTER_4.String()},
+	}
+	if diff := DiffRegisterDumps(a, b); !reflect.DeepEqual(diff, expected) {
+		t.Errorf("diff %v, expected %v", diff, expected)
+	}
+
+	//Calibration change shows up also on compensated values
+	b = a
+	b[REGISTER_CALIB00-REGISTERDUMP_FIRST], b[REGISTER_CALIB00-REGISTERDUMP_FIRST+1] = 0, 0 //dig_T1=0
+	names := []string{}
+	for _, d := range DiffRegisterDumps(a, b) {
+		names = append(names, d.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"dig_T1", "pressure", "temperature", "humidity"}) {
+		t.Errorf("calibration change diff %v", names)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Register level encoding and decoding of control and status registers.
+Bit packing is here so I2C driver, future SPI driver, emulators and dump tools share it
+*/
+package BME280golib
+
+// CtrlHumRegister 0xF2. Bits 7..3 are reserved, datasheet requires to preserve them on write
+type CtrlHumRegister struct {
+	Osrs_h   Oversample
+	Reserved byte //Bits 7..3 as they were read from chip
+}
+
+func DecodeCtrlHumRegister(b byte) CtrlHumRegister {
+	return CtrlHumRegister{Osrs_h: Oversample(b & 0x07), Reserved: b & 0xF8}
+}
+
+func (a CtrlHumRegister) Encode() byte {
+	return a.Reserved&0xF8 | byte(a.Osrs_h)&0x07
+}
+
+// CtrlMeasRegister 0xF4
+type CtrlMeasRegister struct {
+	Osrs_t Oversample
+	Osrs_p Oversample
+	Mode   DeviceMode
+}
+
+// DecodeCtrlMeasRegister, both 01 and 10 mode bits are forced mode
+func DecodeCtrlMeasRegister(b byte) CtrlMeasRegister {
+	mode := DeviceMode(b & 0x03)
+	if mode == 2 {
+		mode = MODE_FORCED
+	}
+	return CtrlMeasRegister{Osrs_t: Oversample(b >> 5), Osrs_p: Oversample((b >> 2) & 0x07), Mode: mode}
+}
+
+func (a CtrlMeasRegister) Encode() byte {
+	return byte(a.Osrs_t)&0x07<<5 | byte(a.Osrs_p)&0x07<<2 | byte(a.Mode)&0x03
+}
+
+// ConfigRegister 0xF5. Bit 1 is reserved
+type ConfigRegister struct {
+	T_sb     StandbyDurationSetting
+	Filter   FilterSetting
+	Spi3w_en bool
+}
+
+func DecodeConfigRegister(b byte) ConfigRegister {
+	return ConfigRegister{T_sb: StandbyDurationSetting(b >> 5), Filter: FilterSetting((b >> 2) & 0x07), Spi3w_en: b&0x01 != 0}
+}
+
+func (a ConfigRegister) Encode() byte {
+	result := byte(a.T_sb)&0x07<<5 | byte(a.Filter)&0x07<<2
+	if a.Spi3w_en {
+		result |= 0x01
+	}
+	return result
+}
+
+// StatusRegister 0xF3
+type StatusRegister struct {
+	Measuring bool //Conversion is running
+	Im_update bool //NVM data is being copied to image registers
+}
+
+func DecodeStatusRegister(b byte) StatusRegister {
+	return StatusRegister{Measuring: b&0x08 != 0, Im_update: b&0x01 != 0}
+}
+
+func (a StatusRegister) Encode() byte {
+	result := byte(0)
+	if a.Measuring {
+		result |= 0x08
+	}
+	if a.Im_update {
+		result |= 0x01
+	}
+	return result
+}
+
+// ConfigToRegisters splits configuration to registers. ctrlHumReserved is reserved bits read from chip
+func ConfigToRegisters(config BME280Config, ctrlHumReserved byte) (CtrlHumRegister, CtrlMeasRegister, ConfigRegister) {
+	return CtrlHumRegister{Osrs_h: config.Oversample_humidity, Reserved: ctrlHumReserved},
+		CtrlMeasRegister{Osrs_t: config.Oversample_temperature, Osrs_p: config.Oversample_pressure, Mode: config.Mode},
+		ConfigRegister{T_sb: config.Standby, Filter: config.Filter}
+}
+
+// ConfigFromRegisters combines configuration from registers
+func ConfigFromRegisters(hum CtrlHumRegister, meas CtrlMeasRegister, conf ConfigRegister) BME280Config {
+	return BME280Config{
+		Oversample_humidity:    hum.Osrs_h,
+		Oversample_pressure:    meas.Osrs_p,
+		Oversample_temperature: meas.Osrs_t,
+		Mode:                   meas.Mode,
+		Standby:                conf.T_sb,
+		Filter:                 conf.Filter,
+	}
+}
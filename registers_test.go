@@ -0,0 +1,51 @@
+package BME280golib
+
+import "testing"
+
+// Config register layout by datasheet: t_sb bits 7..5, filter bits 4..2, spi3w_en bit 0
+func TestConfigRegisterBitPositions(t *testing.T) {
+	cases := []struct {
+		conf ConfigRegister
+		want byte
+	}{
+		{ConfigRegister{T_sb: STANDBYDURATION_0_5, Filter: FILTER_NO}, 0x00},
+		{ConfigRegister{T_sb: STANDBYDURATION_1000, Filter: FILTER_NO}, 0xA0},
+		{ConfigRegister{T_sb: STANDBYDURATION_20, Filter: FILTER_NO}, 0xE0},
+		{ConfigRegister{T_sb: STANDBYDURATION_0_5, Filter: FILTER_16}, 0x10},
+		{ConfigRegister{T_sb: STANDBYDURATION_0_5, Filter: FILTER_2}, 0x04},
+		{ConfigRegister{T_sb: STANDBYDURATION_62_5, Filter: FILTER_4, Spi3w_en: true}, 0x29},
+	}
+	for _, c := range cases {
+		got := c.conf.Encode()
+		if got != c.want {
+			t.Errorf("%+v encoded 0x%02X, expected 0x%02X", c.conf, got, c.want)
+		}
+		if back := DecodeConfigRegister(got); back != c.conf {
+			t.Errorf("0x%02X decoded %+v, expected %+v", got, back, c.conf)
+		}
+	}
+}
+
+func TestConfigureWritesConfigRegister(t *testing.T) {
+	bus := newFakeBME280()
+	dev, err := CreateBME280I2C(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_16, Oversample_temperature: OVRSAMPLE_2,
+		Mode: MODE_NORMAL, Standby: STANDBYDURATION_1000, Filter: FILTER_16}
+	err = dev.Configure(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bus.regs[REGISTER_CONFIG] != 0xB0 {
+		t.Errorf("config register 0x%02X, expected 0xB0", bus.regs[REGISTER_CONFIG])
+	}
+	back, err := dev.ReadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back != config {
+		t.Errorf("read back %s, expected %s", back, config)
+	}
+}
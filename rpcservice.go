@@ -0,0 +1,175 @@
+//go:build !tinygo
+
+/*
+JSON-RPC service for BME280Device (net/rpc/jsonrpc)
+Higher level than remote I2C, only measurement results and configuration go over network.
+Client implements BME280Device so local and remote sensors are interchangeable
+*/
+package BME280golib
+
+import (
+	"io"
+	"math"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+)
+
+const RPC_DEFAULTSERVICENAME = "BME280"
+
+// RPCEmpty is for rpc methods without arguments or reply
+type RPCEmpty struct{}
+
+// RPCMeasurement is HumTempPressureMeas on wire. JSON do not have infinities or NaN, those are marked with -1 or 1 and RPC_NAN
+type RPCMeasurement struct {
+	Temperature    float64
+	Rh             float64
+	Pressure       float64
+	TemperatureInf int
+	RhInf          int
+	PressureInf    int
+}
+
+const RPC_NAN = 2 //Marker for NaN on RPCMeasurement
+
+func rpcFloat(f float64) (float64, int) {
+	if math.IsNaN(f) {
+		return 0, RPC_NAN
+	}
+	if math.IsInf(f, 1) {
+		return 0, 1
+	}
+	if math.IsInf(f, -1) {
+		return 0, -1
+	}
+	return f, 0
+}
+
+func ToRPCMeasurement(meas HumTempPressureMeas) RPCMeasurement {
+	result := RPCMeasurement{}
+	result.Temperature, result.TemperatureInf = rpcFloat(meas.Temperature)
+	result.Rh, result.RhInf = rpcFloat(meas.Rh)
+	result.Pressure, result.PressureInf = rpcFloat(meas.Pressure)
+	return result
+}
+
+func fromRPCFloat(f float64, marker int) float64 {
+	switch marker {
+	case 0:
+		return f
+	case RPC_NAN:
+		return math.NaN()
+	}
+	return math.Inf(marker)
+}
+
+func (a RPCMeasurement) Meas() HumTempPressureMeas {
+	return HumTempPressureMeas{
+		Temperature: fromRPCFloat(a.Temperature, a.TemperatureInf),
+		Rh:          fromRPCFloat(a.Rh, a.RhInf),
+		Pressure:    fromRPCFloat(a.Pressure, a.PressureInf),
+	}
+}
+
+// BME280RPCService exposes BME280Device methods. Calls are serialized
+type BME280RPCService struct {
+	dev BME280Device
+	mu  sync.Mutex
+}
+
+func (p *BME280RPCService) Read(args RPCEmpty, reply *RPCMeasurement) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	meas, err := p.dev.Read()
+	*reply = ToRPCMeasurement(meas)
+	return err
+}
+
+func (p *BME280RPCService) Configure(config BME280Config, reply *RPCEmpty) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dev.Configure(config)
+}
+
+func (p *BME280RPCService) SoftReset(args RPCEmpty, reply *RPCEmpty) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dev.SoftReset()
+}
+
+func (p *BME280RPCService) GetCalibration(args RPCEmpty, reply *CalibrationRegs) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var err error
+	*reply, err = p.dev.GetCalibration()
+	return err
+}
+
+// RegisterBME280RPC adds device to rpc server. Name allows many sensors on same server
+func RegisterBME280RPC(server *rpc.Server, name string, dev BME280Device) error {
+	return server.RegisterName(name, &BME280RPCService{dev: dev})
+}
+
+// ServeBME280RPC serves single device with RPC_DEFAULTSERVICENAME. Returns when listener fails (is closed)
+func ServeBME280RPC(l net.Listener, dev BME280Device) error {
+	server := rpc.NewServer()
+	err := RegisterBME280RPC(server, RPC_DEFAULTSERVICENAME, dev)
+	if err != nil {
+		return err
+	}
+	for {
+		conn, errAccept := l.Accept()
+		if errAccept != nil {
+			return errAccept
+		}
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// BME280RPCClient implements BME280Device by calling remote BME280RPCService
+type BME280RPCClient struct {
+	client *rpc.Client
+	name   string
+}
+
+func CreateBME280RPCClient(conn io.ReadWriteCloser, name string) BME280RPCClient {
+	return BME280RPCClient{client: jsonrpc.NewClient(conn), name: name}
+}
+
+// DialBME280RPC connects to device served with ServeBME280RPC
+func DialBME280RPC(address string) (BME280RPCClient, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return BME280RPCClient{}, err
+	}
+	return CreateBME280RPCClient(conn, RPC_DEFAULTSERVICENAME), nil
+}
+
+// Close closes connection, remote device stays open
+func (p *BME280RPCClient) Close() error {
+	return p.client.Close()
+}
+
+func (p *BME280RPCClient) Configure(config BME280Config) error {
+	return p.client.Call(p.name+".Configure", config, &RPCEmpty{})
+}
+
+func (p *BME280RPCClient) Read() (HumTempPressureMeas, error) {
+	var reply RPCMeasurement
+	err := p.client.Call(p.name+".Read", RPCEmpty{}, &reply)
+	if err != nil {
+		return HumTempPressureMeas{}, err
+	}
+	return reply.Meas(), nil
+}
+
+func (p *BME280RPCClient) SoftReset() error {
+	return p.client.Call(p.name+".SoftReset", RPCEmpty{}, &RPCEmpty{})
+}
+
+func (p *BME280RPCClient) GetCalibration() (CalibrationRegs, error) {
+	var reply CalibrationRegs
+	err := p.client.Call(p.name+".GetCalibration", RPCEmpty{}, &reply)
+	return reply, err
+}
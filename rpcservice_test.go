@@ -0,0 +1,108 @@
+//go:build !tinygo
+
+package BME280golib
+
+import (
+	"math"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// fixedMeasDevice gives set measurement instead of reading chip
+type fixedMeasDevice struct {
+	BME280Device
+	meas HumTempPressureMeas
+}
+
+func (p *fixedMeasDevice) Read() (HumTempPressureMeas, error) {
+	return p.meas, nil
+}
+
+func TestRPCLoopback(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("no loopback", err)
+	}
+	defer l.Close()
+	bus := newFakeBME280()
+	dev, err := CreateBME280I2C(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ServeBME280RPC(l, &dev)
+
+	client, err := DialBME280RPC(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	config := BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_16, Oversample_temperature: OVRSAMPLE_2, Mode: MODE_NORMAL, Standby: STANDBYDURATION_62_5, Filter: FILTER_16}
+	err = client.Configure(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := dev.ReadConfig(); got != config {
+		t.Errorf("configured over RPC %s, on chip %s", config, got)
+	}
+	meas, err := client.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meas.Temperature < 25.07 || 25.09 < meas.Temperature || meas.Pressure < 100600 || 100700 < meas.Pressure {
+		t.Errorf("read over RPC %v", meas)
+	}
+	calib, err := client.GetCalibration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, _ := dev.GetCalibration()
+	if calib != local {
+		t.Errorf("calibration over RPC %#v, local %#v", calib, local)
+	}
+
+	//Errors are reported as text
+	bus.failNext(REGISTER_CTRL_HUM, syscall.EIO)
+	err = client.Configure(config)
+	if _, ok := err.(rpc.ServerError); !ok {
+		t.Errorf("failed configure gave %v", err)
+	}
+	bus.failNext(REGISTER_DATA, syscall.EIO)
+	_, err = client.Read()
+	if err == nil || !strings.Contains(err.Error(), syscall.EIO.Error()) {
+		t.Errorf("failed read gave %v", err)
+	}
+}
+
+func TestRPCNonFinite(t *testing.T) {
+	dev := &fixedMeasDevice{}
+	server := rpc.NewServer()
+	err := RegisterBME280RPC(server, "inside", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConn, clientConn := net.Pipe()
+	go server.ServeCodec(jsonrpc.NewServerCodec(serverConn))
+	client := CreateBME280RPCClient(clientConn, "inside")
+	defer client.Close()
+
+	for _, meas := range []HumTempPressureMeas{
+		{Temperature: 21.5, Rh: 40.25, Pressure: 101325},
+		{Temperature: math.Inf(1), Rh: math.Inf(-1), Pressure: math.NaN()},
+		{Temperature: math.NaN(), Rh: 0, Pressure: math.Inf(-1)},
+	} {
+		dev.meas = meas
+		got, err := client.Read()
+		if err != nil {
+			t.Fatalf("%v: %v", meas, err)
+		}
+		same := func(a, b float64) bool { return a == b || (math.IsNaN(a) && math.IsNaN(b)) }
+		if !same(got.Temperature, meas.Temperature) || !same(got.Rh, meas.Rh) || !same(got.Pressure, meas.Pressure) {
+			t.Errorf("sent %v got %v", meas, got)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Sampler reads device at fixed rate.
+Schedule is calculated from start time (start + n*period) so timing does not drift even if reads take time.
+Results are delivered by callback or channel
+*/
+package BME280golib
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type SamplerAlign byte
+
+const (
+	SAMPLERALIGN_NONE      SamplerAlign = iota //First sample immediately
+	SAMPLERALIGN_WALLCLOCK                     //Samples on multiples of period on wall clock, like on every full second
+	SAMPLERALIGN_CHIPCYCLE                     //Period is rounded up to multiple of chip measurement cycle, no sample is read twice. Reads are placed between conversions if device is BME280TimingSyncer
+)
+
+func (a SamplerAlign) String() string {
+	switch a {
+	case SAMPLERALIGN_NONE:
+		return "none"
+	case SAMPLERALIGN_WALLCLOCK:
+		return "wallclock"
+	case SAMPLERALIGN_CHIPCYCLE:
+		return "chipcycle"
+	}
+	return "INVALID"
+}
+
+// Sample is one result from sampler
+type Sample struct {
+	N         int       //Sequence number, starting from 0
+	Scheduled time.Time //When sample was scheduled to be read
+	Time      time.Time //When read was completed
+	Skipped   int       //How many scheduled samples were skipped before this because earlier reads took too long
+	Meas      HumTempPressureMeas
+	Info      SampleInfo //Zero if device does not implement BME280SampleInfoReader
+}
+
+type Sampler struct {
+	Period     time.Duration
+	Align      SamplerAlign
+	MaxSamples int          //0=run until cancelled
+	Config     BME280Config //Configuration on device. Used on SAMPLERALIGN_CHIPCYCLE and forced mode is triggered before each read
+
+	dev BME280Device
+}
+
+// Timestamp is estimated conversion completion time if device tells it, otherwise read time
+func (a Sample) Timestamp() time.Time {
+	if a.Info.Completed.IsZero() {
+		return a.Time
+	}
+	return a.Info.Completed
+}
+
+func CreateSampler(dev BME280Device, period time.Duration) Sampler {
+	return Sampler{dev: dev, Period: period}
+}
+
+// EffectivePeriod is period after alignment to chip cycle
+func (p *Sampler) EffectivePeriod() time.Duration {
+	if p.Align != SAMPLERALIGN_CHIPCYCLE {
+		return p.Period
+	}
+	cycle := p.Config.CycleDuration()
+	if cycle <= 0 {
+		return p.Period
+	}
+	n := (p.Period + cycle - 1) / cycle
+	if n < 1 {
+		n = 1
+	}
+	return n * cycle
+}
+
+func (p *Sampler) firstTime(now time.Time, period time.Duration) (time.Time, error) {
+	switch p.Align {
+	case SAMPLERALIGN_WALLCLOCK:
+		return now.Truncate(period).Add(period), nil
+	case SAMPLERALIGN_CHIPCYCLE:
+		syncer, haz := p.dev.(BME280TimingSyncer)
+		if !haz || p.Config.Mode != MODE_NORMAL {
+			break
+		}
+		completed, err := syncer.SyncTiming(2 * p.Config.CycleDuration())
+		if err != nil {
+			return now, fmt.Errorf("synchronizing to chip failed %w", err)
+		}
+		return completed.Add(period + p.Config.Standby.Duration()/2), nil //Middle of standby
+	}
+	return now, nil
+}
+
+func (p *Sampler) read() (HumTempPressureMeas, SampleInfo, error) {
+	if p.Config.Mode == MODE_FORCED {
+		err := p.dev.Configure(p.Config)
+		if err != nil {
+			return HumTempPressureMeas{}, SampleInfo{}, fmt.Errorf("triggering forced measurement failed %w", err)
+		}
+		time.Sleep(p.Config.MeasurementDurationMaximum())
+	}
+	infoReader, haz := p.dev.(BME280SampleInfoReader)
+	if haz {
+		return infoReader.ReadWithInfo()
+	}
+	meas, err := p.dev.Read()
+	return meas, SampleInfo{}, err
+}
+
+/*
+Run samples until MaxSamples is reached (returns nil), context is cancelled (returns context error),
+read fails or callback returns error.
+*/
+func (p *Sampler) Run(ctx context.Context, callback func(sample Sample) error) error {
+	period := p.EffectivePeriod()
+	if period <= 0 {
+		return fmt.Errorf("invalid sampling period %v", period)
+	}
+	start, err := p.firstTime(time.Now(), period)
+	if err != nil {
+		return err
+	}
+	skipped := 0
+	slot := 0
+	for n := 0; p.MaxSamples == 0 || n < p.MaxSamples; n++ {
+		scheduled := start.Add(time.Duration(slot) * period)
+		wait := time.Until(scheduled)
+		if 0 < wait {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		meas, info, err := p.read()
+		if err != nil {
+			return err
+		}
+		err = callback(Sample{N: n, Scheduled: scheduled, Time: time.Now(), Skipped: skipped, Meas: meas, Info: info})
+		if err != nil {
+			return err
+		}
+
+		//Slots more than period late are skipped. Schedule stays fixed if read took longer than period
+		slot++
+		late := time.Since(start.Add(time.Duration(slot) * period))
+		skipped = 0
+		if period <= late {
+			skipped = int(late / period)
+			slot += skipped
+		}
+	}
+	return nil
+}
+
+// RunChannel is Run with delivery to channel. Channel is closed when sampling ends
+func (p *Sampler) RunChannel(ctx context.Context, out chan<- Sample) error {
+	defer close(out)
+	return p.Run(ctx, func(sample Sample) error {
+		select {
+		case out <- sample:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
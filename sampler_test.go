@@ -0,0 +1,191 @@
+package BME280golib
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// delayDevice takes delays[n] on n:th read. Only BME280Device methods, so sampler uses plain Read
+type delayDevice struct {
+	BME280Device
+	delays []time.Duration
+	reads  int
+	err    error //Returned after delays are used, if set
+}
+
+func (p *delayDevice) Read() (HumTempPressureMeas, error) {
+	n := p.reads
+	p.reads++
+	if n < len(p.delays) {
+		time.Sleep(p.delays[n])
+	} else if p.err != nil {
+		return HumTempPressureMeas{}, p.err
+	}
+	return p.BME280Device.Read()
+}
+
+func createDelayDevice(t *testing.T, delays []time.Duration) *delayDevice {
+	t.Helper()
+	dev, err := CreateBME280I2C(newFakeBME280())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &delayDevice{BME280Device: &dev, delays: delays}
+}
+
+func TestSamplerRun(t *testing.T) {
+	const ms = time.Millisecond
+	for _, tc := range []struct {
+		name       string
+		period     time.Duration
+		delays     []time.Duration
+		maxSamples int
+		skipped    []int //Expected Sample.Skipped
+		slots      []int //Expected schedule slot of each sample
+	}{
+		{"fast reads", 20 * ms, []time.Duration{2 * ms, 2 * ms, 2 * ms, 2 * ms, 2 * ms}, 5, []int{0, 0, 0, 0, 0}, []int{0, 1, 2, 3, 4}},
+		{"reads take half period", 40 * ms, []time.Duration{20 * ms, 20 * ms, 20 * ms, 20 * ms}, 4, []int{0, 0, 0, 0}, []int{0, 1, 2, 3}},
+		//Read of slot 1 completes at 140ms. Slot 2 (80ms) is skipped, slot 3 (120ms) is read late without waiting
+		{"slow read", 40 * ms, []time.Duration{0, 100 * ms, 0, 0}, 4, []int{0, 0, 1, 0}, []int{0, 1, 3, 4}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sampler := CreateSampler(createDelayDevice(t, tc.delays), tc.period)
+			sampler.MaxSamples = tc.maxSamples
+			samples := []Sample{}
+			err := sampler.Run(context.Background(), func(sample Sample) error {
+				samples = append(samples, sample)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(samples) != tc.maxSamples {
+				t.Fatalf("got %v samples", len(samples))
+			}
+			skipped := []int{}
+			slots := []int{}
+			for i, sample := range samples {
+				if sample.N != i {
+					t.Errorf("sample %v have N=%v", i, sample.N)
+				}
+				skipped = append(skipped, sample.Skipped)
+				offset := sample.Scheduled.Sub(samples[0].Scheduled)
+				if offset%tc.period != 0 {
+					t.Errorf("sample %v scheduled off grid %v", i, offset)
+				}
+				slots = append(slots, int(offset/tc.period))
+				if sample.Time.Before(sample.Scheduled) {
+					t.Errorf("sample %v read before schedule", i)
+				}
+			}
+			if !reflect.DeepEqual(skipped, tc.skipped) || !reflect.DeepEqual(slots, tc.slots) {
+				t.Errorf("skipped %v slots %v, expected %v %v", skipped, slots, tc.skipped, tc.slots)
+			}
+		})
+	}
+}
+
+func TestSamplerStop(t *testing.T) {
+	errCallback := errors.New("callback failed")
+	for _, tc := range []struct {
+		name     string
+		readErr  error
+		stopAt   int   //Callback cancels or fails on this sample
+		cbErr    error //nil=cancel context
+		expected error
+		samples  int
+	}{
+		{"cancel", nil, 1, nil, context.Canceled, 2},
+		{"callback error", nil, 2, errCallback, errCallback, 3},
+		{"read error", syscall.EIO, -1, nil, syscall.EIO, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dev := createDelayDevice(t, []time.Duration{0, 0, 0})
+			dev.err = tc.readErr
+			sampler := CreateSampler(dev, 5*time.Millisecond)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			samples := 0
+			err := sampler.Run(ctx, func(sample Sample) error {
+				samples++
+				if sample.N == tc.stopAt {
+					if tc.cbErr != nil {
+						return tc.cbErr
+					}
+					cancel()
+				}
+				return nil
+			})
+			if !errors.Is(err, tc.expected) || samples != tc.samples {
+				t.Errorf("stopped with %v after %v samples, expected %v after %v", err, samples, tc.expected, tc.samples)
+			}
+		})
+	}
+}
+
+func TestSamplerRunChannel(t *testing.T) {
+	sampler := CreateSampler(createDelayDevice(t, nil), time.Millisecond)
+	sampler.MaxSamples = 3
+	out := make(chan Sample)
+	result := make(chan error, 1)
+	go func() {
+		result <- sampler.RunChannel(context.Background(), out)
+	}()
+	n := 0
+	for sample := range out { //Ends when channel is closed
+		if sample.N != n {
+			t.Errorf("got sample %v, expected %v", sample.N, n)
+		}
+		n++
+	}
+	if err := <-result; err != nil || n != 3 {
+		t.Errorf("RunChannel gave %v after %v samples", err, n)
+	}
+
+	//Cancelled while nobody reads channel
+	ctx, cancel := context.WithCancel(context.Background())
+	out = make(chan Sample)
+	go func() {
+		result <- sampler.RunChannel(ctx, out)
+	}()
+	<-out
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled RunChannel gave %v", err)
+	}
+	if _, open := <-out; open {
+		t.Errorf("channel not closed after cancel")
+	}
+}
+
+func TestSamplerEffectivePeriod(t *testing.T) {
+	config := BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_1, Oversample_temperature: OVRSAMPLE_1, Mode: MODE_NORMAL, Standby: STANDBYDURATION_62_5}
+	cycle := config.CycleDuration()
+	for _, tc := range []struct {
+		align    SamplerAlign
+		period   time.Duration
+		expected time.Duration
+	}{
+		{SAMPLERALIGN_NONE, 15 * time.Millisecond, 15 * time.Millisecond},
+		{SAMPLERALIGN_WALLCLOCK, time.Second, time.Second},
+		{SAMPLERALIGN_CHIPCYCLE, time.Millisecond, cycle}, //At least one cycle
+		{SAMPLERALIGN_CHIPCYCLE, cycle, cycle},
+		{SAMPLERALIGN_CHIPCYCLE, cycle + time.Nanosecond, 2 * cycle}, //Rounded up
+		{SAMPLERALIGN_CHIPCYCLE, 3*cycle - time.Nanosecond, 3 * cycle},
+	} {
+		sampler := Sampler{Period: tc.period, Align: tc.align, Config: config}
+		if got := sampler.EffectivePeriod(); got != tc.expected {
+			t.Errorf("%s %v: effective period %v, expected %v", tc.align, tc.period, got, tc.expected)
+		}
+	}
+
+	sampler := CreateSampler(createDelayDevice(t, nil), 0)
+	err := sampler.Run(context.Background(), func(sample Sample) error { return nil })
+	if err == nil {
+		t.Errorf("zero period accepted")
+	}
+}
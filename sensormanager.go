@@ -0,0 +1,191 @@
+/*
+Manager for many sensors, like two BME280 on same bus (0x76 and 0x77) for redundancy.
+Forced measurements are triggered on all sensors back to back and results are collected into one record.
+Failing sensor does not stop others
+*/
+package BME280golib
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BME280ForcedTrigger is implemented by devices that can start forced measurement without writing whole configuration
+type BME280ForcedTrigger interface {
+	TriggerForced() error
+}
+
+// TriggerForced starts forced measurement with configured oversampling. Only ctrl_meas is written
+func (p *BME280I2C) TriggerForced() error {
+	if !p.configured {
+		return fmt.Errorf("not configured, can not trigger")
+	}
+	_, meas, _ := ConfigToRegisters(p.config, 0)
+	meas.Mode = MODE_FORCED
+	writeTime := time.Now()
+	err := p.dev.WriteReg(REGISTER_CTRL_MEAS, meas.Encode())
+	if err != nil {
+		return err
+	}
+	p.config.Mode = MODE_FORCED
+	p.configuredAt = writeTime
+	return nil
+}
+
+// SensorReading is result of one sensor on record
+type SensorReading struct {
+	Name      string
+	Triggered time.Time //When trigger write was completed (or read on normal mode)
+	Meas      HumTempPressureMeas
+	Info      SampleInfo //Zero if device does not implement BME280SampleInfoReader
+	Err       error
+}
+
+func (a SensorReading) String() string {
+	if a.Err != nil {
+		return fmt.Sprintf("%s: ERROR %v", a.Name, a.Err)
+	}
+	return fmt.Sprintf("%s: %.2fC %.2f%%RH %.1fPa", a.Name, a.Meas.Temperature, a.Meas.Rh, a.Meas.Pressure)
+}
+
+// SensorRecord is synchronized result from all sensors
+type SensorRecord struct {
+	Time     time.Time //When first sensor was triggered
+	Readings []SensorReading
+}
+
+// Err joins errors of all sensors, nil if all succeeded
+func (a SensorRecord) Err() error {
+	var errs []error
+	for _, reading := range a.Readings {
+		if reading.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", reading.Name, reading.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Valid readings, without failed
+func (a SensorRecord) Valid() []SensorReading {
+	result := []SensorReading{}
+	for _, reading := range a.Readings {
+		if reading.Err == nil {
+			result = append(result, reading)
+		}
+	}
+	return result
+}
+
+type managedSensor struct {
+	name string
+	dev  BME280Device
+}
+
+/*
+SensorManager owns many devices with same configuration.
+On forced mode Sample triggers all sensors (TriggerForced if device implements BME280ForcedTrigger, otherwise Configure),
+waits maximum measurement time and reads all. On normal mode sensors are just read
+*/
+type SensorManager struct {
+	Config  BME280Config
+	sensors []managedSensor
+}
+
+func CreateSensorManager(config BME280Config) SensorManager {
+	return SensorManager{Config: config}
+}
+
+// Add sensor. Names must be unique
+func (p *SensorManager) Add(name string, dev BME280Device) error {
+	for _, s := range p.sensors {
+		if s.name == name {
+			return fmt.Errorf("sensor %s already added", name)
+		}
+	}
+	p.sensors = append(p.sensors, managedSensor{name: name, dev: dev})
+	return nil
+}
+
+func (p *SensorManager) Names() []string {
+	result := make([]string, len(p.sensors))
+	for i, s := range p.sensors {
+		result[i] = s.name
+	}
+	return result
+}
+
+// Configure writes Config to all sensors. Tries all, returns joined errors
+func (p *SensorManager) Configure() error {
+	var errs []error
+	for _, s := range p.sensors {
+		err := s.dev.Configure(p.Config)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *SensorManager) trigger(dev BME280Device) error {
+	trigger, haz := dev.(BME280ForcedTrigger)
+	if haz {
+		return trigger.TriggerForced()
+	}
+	return dev.Configure(p.Config)
+}
+
+// Sample takes one synchronized measurement from all sensors. Errors are per sensor on record
+func (p *SensorManager) Sample() SensorRecord {
+	result := SensorRecord{Time: time.Now(), Readings: make([]SensorReading, len(p.sensors))}
+	for i, s := range p.sensors {
+		result.Readings[i].Name = s.name
+		if p.Config.Mode == MODE_FORCED {
+			result.Readings[i].Err = p.trigger(s.dev)
+		}
+		result.Readings[i].Triggered = time.Now() //Write takes time on slow transports, conversion is running only after it
+	}
+	if p.Config.Mode == MODE_FORCED && 0 < len(p.sensors) {
+		//Last sensor was triggered latest
+		time.Sleep(time.Until(result.Readings[len(p.sensors)-1].Triggered.Add(p.Config.MeasurementDurationMaximum())))
+	}
+
+	for i, s := range p.sensors {
+		reading := &result.Readings[i]
+		if reading.Err != nil {
+			reading.Err = fmt.Errorf("trigger failed %w", reading.Err)
+			continue
+		}
+		infoReader, haz := s.dev.(BME280SampleInfoReader)
+		if haz {
+			reading.Meas, reading.Info, reading.Err = infoReader.ReadWithInfo()
+		} else {
+			reading.Meas, reading.Err = s.dev.Read()
+		}
+	}
+	return result
+}
+
+// SoftReset resets all sensors. Tries all, returns joined errors
+func (p *SensorManager) SoftReset() error {
+	var errs []error
+	for _, s := range p.sensors {
+		err := s.dev.SoftReset()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes all sensors
+func (p *SensorManager) Close() error {
+	var errs []error
+	for _, s := range p.sensors {
+		err := s.dev.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,134 @@
+package BME280golib
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// timedBus is slow transport. Tells when trigger write completed and when data was read after it
+type timedBus struct {
+	*fakeBME280
+	writeDelay  time.Duration
+	writeErr    error
+	triggerDone time.Time
+	dataRead    time.Time
+}
+
+func (p *timedBus) WriteReg(address byte, value byte) error {
+	time.Sleep(p.writeDelay)
+	if p.writeErr != nil {
+		return p.writeErr
+	}
+	err := p.fakeBME280.WriteReg(address, value)
+	if address == REGISTER_CTRL_MEAS {
+		p.triggerDone, p.dataRead = time.Now(), time.Time{}
+	}
+	return err
+}
+
+func (p *timedBus) ReadRegsInto(address byte, buf []byte) error {
+	if address == REGISTER_DATA && p.dataRead.IsZero() {
+		p.dataRead = time.Now()
+	}
+	return p.fakeBME280.ReadRegsInto(address, buf)
+}
+
+var managerConfig = BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_1, Oversample_temperature: OVRSAMPLE_1, Mode: MODE_FORCED}
+
+func createManaged(t *testing.T, config BME280Config, names ...string) (SensorManager, []*timedBus) {
+	t.Helper()
+	manager := CreateSensorManager(config)
+	buses := []*timedBus{}
+	for _, name := range names {
+		bus := &timedBus{fakeBME280: newFakeBME280()}
+		dev, err := CreateBME280I2C(bus)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = manager.Add(name, &dev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buses = append(buses, bus)
+	}
+	err := manager.Configure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manager, buses
+}
+
+// Trigger write takes longer than measurement. Data must not be read before measurement is completed
+func TestManagerForcedWait(t *testing.T) {
+	manager, buses := createManaged(t, managerConfig, "a", "b")
+	for _, bus := range buses {
+		bus.writeDelay = 2 * managerConfig.MeasurementDurationMaximum()
+	}
+	record := manager.Sample()
+	if err := record.Err(); err != nil {
+		t.Fatal(err)
+	}
+	for i, bus := range buses {
+		if waited := bus.dataRead.Sub(bus.triggerDone); waited < managerConfig.MeasurementDurationMaximum() {
+			t.Errorf("sensor %v read %v after trigger, measurement takes %v", i, waited, managerConfig.MeasurementDurationMaximum())
+		}
+		if record.Readings[i].Triggered.Before(bus.triggerDone) {
+			t.Errorf("sensor %v triggered time before trigger write completed", i)
+		}
+	}
+	if len(record.Valid()) != 2 {
+		t.Errorf("valid readings %v", record.Valid())
+	}
+}
+
+func TestManagerSensorErrors(t *testing.T) {
+	manager, buses := createManaged(t, managerConfig, "a", "b", "c")
+	errWrite := errors.New("bus stuck")
+	buses[1].writeErr = errWrite                  //Trigger fails
+	buses[2].failNext(REGISTER_DATA, syscall.EIO) //Read fails
+
+	record := manager.Sample()
+	if record.Readings[0].Err != nil {
+		t.Errorf("working sensor failed %v", record.Readings[0].Err)
+	}
+	if err := record.Readings[1].Err; !errors.Is(err, errWrite) || !strings.Contains(err.Error(), "trigger failed") {
+		t.Errorf("trigger failure reported as %v", err)
+	}
+	if err := record.Readings[2].Err; !errors.Is(err, syscall.EIO) {
+		t.Errorf("read failure reported as %v", err)
+	}
+	err := record.Err()
+	if !errors.Is(err, errWrite) || !errors.Is(err, syscall.EIO) || !strings.Contains(err.Error(), "b: ") || !strings.Contains(err.Error(), "c: ") {
+		t.Errorf("record error %v", err)
+	}
+	valid := record.Valid()
+	if len(valid) != 1 || valid[0].Name != "a" || valid[0].Meas.Temperature < 25.07 || 25.09 < valid[0].Meas.Temperature {
+		t.Errorf("valid readings %v", valid)
+	}
+
+	ok := SensorRecord{Readings: []SensorReading{{Name: "a"}}}
+	if ok.Err() != nil {
+		t.Errorf("record without failures gave %v", ok.Err())
+	}
+}
+
+// Normal mode sensors are read without triggering
+func TestManagerNormalMode(t *testing.T) {
+	config := managerConfig
+	config.Mode = MODE_NORMAL
+	manager, buses := createManaged(t, config, "a", "b")
+	writes := buses[0].writes
+	record := manager.Sample()
+	if err := record.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if buses[0].writes != writes {
+		t.Errorf("normal mode sample wrote %v registers", buses[0].writes-writes)
+	}
+	if err := manager.Add("a", nil); err == nil {
+		t.Errorf("duplicate name accepted")
+	}
+}
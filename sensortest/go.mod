@@ -8,3 +8,5 @@ require (
 	github.com/google/shlex v0.0.0-20191202100458-e7afc7fbc510 // indirect
 	tinygo.org/x/drivers v0.27.0 // indirect
 )
+
+replace github.com/hjkoskel/BME280golib => ../
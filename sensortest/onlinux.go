@@ -8,45 +8,16 @@ Built with normal golang compiler
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/hjkoskel/BME280golib"
 )
 
-func IntToOversample(i int) (BME280golib.Oversample, error) {
-	m := map[int]BME280golib.Oversample{
-		0:  BME280golib.OVRSAMPLE_NO,
-		1:  BME280golib.OVRSAMPLE_1,
-		2:  BME280golib.OVRSAMPLE_2,
-		4:  BME280golib.OVRSAMPLE_4,
-		8:  BME280golib.OVRSAMPLE_8,
-		16: BME280golib.OVRSAMPLE_16,
-	}
-
-	result, haz := m[i]
-	if !haz {
-		return result, fmt.Errorf("Invalid oversample %v, use 0,1,2,4,8 or 16", i)
-	}
-	return result, nil
-}
-
-func IntToFilter(i int) (BME280golib.FilterSetting, error) {
-	m := map[int]BME280golib.FilterSetting{
-		0:  BME280golib.FILTER_NO,
-		2:  BME280golib.FILTER_2,
-		4:  BME280golib.FILTER_4,
-		8:  BME280golib.FILTER_8,
-		16: BME280golib.FILTER_16}
-	result, haz := m[i]
-	if !haz {
-		return result, fmt.Errorf("Invalid filter %v, use 0,2,4,8 or 16", i)
-	}
-	return result, nil
-}
-
 type SwParameters struct {
 	RequestedInterval time.Duration
 	I2CBit            bool
@@ -56,17 +27,20 @@ type SwParameters struct {
 }
 
 func GetParsFromFlags() (BME280golib.BME280Config, SwParameters, error) {
-	pHov := flag.Int64("hov", 1, "Oversampling humidity 0=no, 1,2,4,8 or 16")
-	pTov := flag.Int64("tov", 1, "Oversampling temperature 0=no, 1,2,4,8 or 16")
-	pPov := flag.Int64("pov", 1, "Oversampling pressure 0=no, 1,2,4,8 or 16")
-	pMode := flag.Int64("mode", 3, "idle=0, forced=1, normal=3")
+	conf := BME280golib.BME280Config{}
+	flag.TextVar(&conf.Oversample_humidity, "hov", BME280golib.OVRSAMPLE_1, "Oversampling humidity skipped, 1x, 2x, 4x, 8x or 16x")
+	flag.TextVar(&conf.Oversample_temperature, "tov", BME280golib.OVRSAMPLE_1, "Oversampling temperature skipped, 1x, 2x, 4x, 8x or 16x")
+	flag.TextVar(&conf.Oversample_pressure, "pov", BME280golib.OVRSAMPLE_1, "Oversampling pressure skipped, 1x, 2x, 4x, 8x or 16x")
+	flag.TextVar(&conf.Mode, "mode", BME280golib.MODE_NORMAL, "sleep, forced or normal")
 	pInterval := flag.Int64("iv", 0, "requested reading interval in milliseconds")
-	pStandbyTime := flag.Float64("sb", 1000, "standby duration milliseconds")
-	pFilter := flag.Int64("filt", 0, "filter 0,2,4,8 or 16")
+	flag.TextVar(&conf.Standby, "sb", BME280golib.STANDBYDURATION_1000, "standby duration 0.5ms, 10ms, 20ms, 62.5ms, 125ms, 250ms, 500ms or 1000ms")
+	flag.TextVar(&conf.Filter, "filt", BME280golib.FILTER_NO, "filter no, 2, 4, 8 or 16")
 	pI2CaddressBit := flag.Bool("i2cbit", false, "select I2C address false=0x76, true=0x77")
 	pI2CDeviceFile := flag.String("i2cdev", "/dev/i2c-1", "I2C device file")
 	pSoftReset := flag.Bool("softreset", false, "do soft reset at start")
 	pN := flag.Int64("n", 100, "Number of samples measured n=0 is forever")
+	pPreset := flag.String("preset", "", "datasheet use case preset "+strings.Join(BME280golib.PresetNames(), ", ")+". Overrides oversampling, filter, mode and standby. Not with -config")
+	pConfigFile := flag.String("config", "", "JSON configuration file. Overrides oversampling, filter, mode and standby. Not with -preset")
 	flag.Parse()
 
 	swPars := SwParameters{
@@ -77,30 +51,35 @@ func GetParsFromFlags() (BME280golib.BME280Config, SwParameters, error) {
 		SampleCount:       int(*pN),
 	}
 
-	conf := BME280golib.BME280Config{}
-
-	var errPar error
-	conf.Oversample_humidity, errPar = IntToOversample(int(*pHov))
-	if errPar != nil {
-		return conf, swPars, errPar
-	}
-	conf.Oversample_temperature, errPar = IntToOversample(int(*pTov))
-	if errPar != nil {
-		return conf, swPars, errPar
+	if *pPreset != "" && *pConfigFile != "" {
+		return conf, swPars, fmt.Errorf("both -preset and -config given, use only one")
 	}
-	conf.Oversample_pressure, errPar = IntToOversample(int(*pPov))
-	if errPar != nil {
-		return conf, swPars, errPar
+
+	if *pPreset != "" {
+		preset, errPreset := BME280golib.GetPreset(*pPreset)
+		if errPreset != nil {
+			return preset.Config, swPars, errPreset
+		}
+		fmt.Printf("Using preset %s\n", preset)
+		if swPars.RequestedInterval < preset.ForcedInterval {
+			swPars.RequestedInterval = preset.ForcedInterval
+		}
+		return preset.Config, swPars, nil
 	}
 
-	sb := time.Duration(*pStandbyTime*1000) * time.Microsecond
-	fmt.Printf("standby time given %v\n", sb)
-	conf.Standby = BME280golib.GetStandbyDuration(sb)
-	conf.Mode = BME280golib.DeviceMode(*pMode)
-	conf.Filter, errPar = IntToFilter(int(*pFilter))
-	if errPar != nil {
-		return conf, swPars, errPar
+	if *pConfigFile != "" {
+		data, errRead := os.ReadFile(*pConfigFile)
+		if errRead != nil {
+			return conf, swPars, errRead
+		}
+		conf = BME280golib.BME280Config{}
+		errRead = json.Unmarshal(data, &conf)
+		if errRead != nil {
+			return conf, swPars, fmt.Errorf("invalid config file %s %w", *pConfigFile, errRead)
+		}
+		return conf, swPars, conf.GotError()
 	}
+
 	return conf, swPars, conf.GotError()
 }
 
@@ -114,6 +93,9 @@ func GetDeviceAndParameters() (BME280golib.I2CDeviceLayer, SoftwareParameters, e
 	fmt.Printf("\n--Configuration --\n%s\n\nMeasurement duration  typ=%v max=%v\n", conf,
 		conf.MeasurementDurationTypical(),
 		conf.MeasurementDurationMaximum())
+	for _, warning := range conf.Warnings() {
+		fmt.Printf("%s\n", warning)
+	}
 
 	fmt.Printf("Software parameters %#v\n", swpars)
 	cycleDuration := conf.CycleDuration()
@@ -123,21 +105,125 @@ func GetDeviceAndParameters() (BME280golib.I2CDeviceLayer, SoftwareParameters, e
 	fmt.Printf("Running %v long measurement cycle\n", cycleDuration)
 
 	//INIT device
+	dev, errOpen := OpenI2C(swpars.DeviceFileName, swpars.I2CBit)
+	if errOpen != nil {
+		fmt.Printf("%s\n", errOpen)
+		os.Exit(-1)
+	}
+	return dev, SoftwareParameters{
+		SensorConf:   conf,
+		PollInterval: cycleDuration,
+		SampleCount:  swpars.SampleCount}, nil
+}
+
+func OpenI2C(deviceFileName string, i2cBit bool) (BME280golib.I2CDeviceLayer, error) {
 	i2cAddress := BME280golib.BME280DEVICEBIT0
-	if swpars.I2CBit {
+	if i2cBit {
 		i2cAddress = BME280golib.BME280DEVICEBIT1
 	}
 
-	i2cFile, errOpenI2cFile := os.OpenFile(swpars.DeviceFileName, os.O_RDWR, 0600)
+	i2cFile, errOpenI2cFile := os.OpenFile(deviceFileName, os.O_RDWR, 0600)
 	if errOpenI2cFile != nil {
-		fmt.Printf("error opening I2C device file %s  err=%s\n", swpars.DeviceFileName, errOpenI2cFile)
-		os.Exit(-1)
+		return nil, fmt.Errorf("error opening I2C device file %s  err=%s", deviceFileName, errOpenI2cFile)
 	}
 
 	a := BME280golib.CreateI2CSys(i2cFile, i2cAddress)
-	return &a, SoftwareParameters{
-		SensorConf:   conf,
-		PollInterval: cycleDuration}, nil
+	return &a, nil
+}
+
+func readDumpFile(fname string) (BME280golib.RegisterDump, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return BME280golib.RegisterDump{}, err
+	}
+	defer f.Close()
+	result, err := BME280golib.ParseRegisterDump(f)
+	if err != nil {
+		return result, fmt.Errorf("invalid dump file %s %w", fname, err)
+	}
+	return result, nil
+}
+
+func printDiff(a BME280golib.RegisterDump, b BME280golib.RegisterDump) {
+	diffs := BME280golib.DiffRegisterDumps(a, b)
+	if len(diffs) == 0 {
+		fmt.Printf("dumps are identical\n")
+		return
+	}
+	for _, d := range diffs {
+		fmt.Printf("%s\n", d)
+	}
+}
+
+// RunDump is "dump" subcommand. Reads all registers, prints decoded fields and optionally saves and compares
+func RunDump(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	pI2CaddressBit := fs.Bool("i2cbit", false, "select I2C address false=0x76, true=0x77")
+	pI2CDeviceFile := fs.String("i2cdev", "/dev/i2c-1", "I2C device file")
+	pOut := fs.String("o", "", "save dump to file")
+	pAgainst := fs.String("against", "", "compare with saved dump, like from known good unit")
+	fs.Parse(args)
+
+	dev, err := OpenI2C(*pI2CDeviceFile, *pI2CaddressBit)
+	if err != nil {
+		return err
+	}
+	defer dev.Close()
+
+	dump, err := BME280golib.ReadRegisterDump(dev)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s", dump.ToTable())
+
+	if *pOut != "" {
+		err = os.WriteFile(*pOut, []byte(dump.HexDump()), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	if *pAgainst != "" {
+		good, errRead := readDumpFile(*pAgainst)
+		if errRead != nil {
+			return errRead
+		}
+		fmt.Printf("\n--Differences to %s--\n", *pAgainst)
+		printDiff(good, dump)
+	}
+	return nil
+}
+
+// RunDiff is "diff" subcommand, compares two saved dumps
+func RunDiff(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: diff gooddump.txt baddump.txt")
+	}
+	a, err := readDumpFile(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := readDumpFile(args[1])
+	if err != nil {
+		return err
+	}
+	printDiff(a, b)
+	return nil
+}
+
+// RunSubcommand runs dump or diff if given as first argument. Returns false if measurement should be run
+func RunSubcommand() bool {
+	if len(os.Args) < 2 {
+		return false
+	}
+	switch os.Args[1] {
+	case "dump":
+		HandleTermintingError(RunDump(os.Args[2:]))
+		return true
+	case "diff":
+		HandleTermintingError(RunDiff(os.Args[2:]))
+		return true
+	}
+	return false
 }
 
 func HandleTermintingError(err error) {
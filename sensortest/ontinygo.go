@@ -43,6 +43,11 @@ func GetDeviceAndParameters() (BME280golib.I2CDeviceLayer, SoftwareParameters, e
 
 }
 
+// RunSubcommand, no command line on microcontroller
+func RunSubcommand() bool {
+	return false
+}
+
 func HandleTermintingError(err error) {
 	for err != nil {
 		fmt.Printf("FAIL %s\n", err)
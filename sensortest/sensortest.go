@@ -6,6 +6,7 @@ Simple program for testing functionality and options
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -16,36 +17,17 @@ import (
 type SoftwareParameters struct {
 	SensorConf   BME280golib.BME280Config
 	PollInterval time.Duration
+	SampleCount  int //0=forever
 }
 
-type MeasurementResult struct {
-	SamplingTime time.Time
-	Meas         BME280golib.HumTempPressureMeas
-}
-
-func (p *MeasurementResult) ToCsv() string {
-	return fmt.Sprintf("%v\t%.1f\t%.3f\t%.3f", p.SamplingTime.UnixMilli(), p.Meas.Pressure, p.Meas.Temperature, p.Meas.Rh)
-}
-
-//RunBME is example how to collect results with fixed cycle time
-
-func RunBME(sensor BME280golib.BME280Device, cycleDuration time.Duration, chOut chan<- MeasurementResult) error {
-	for {
-		tCycleStart := time.Now()
-		measResult, measErr := sensor.Read()
-		if measErr != nil {
-			return measErr
-		}
-		chOut <- MeasurementResult{Meas: measResult, SamplingTime: time.Now()}
-
-		dur := time.Since(tCycleStart)
-		if dur < cycleDuration { //Took less time than required
-			time.Sleep(cycleDuration - dur)
-		}
-	}
+func ToCsv(sample BME280golib.Sample) string {
+	return fmt.Sprintf("%v\t%.1f\t%.3f\t%.3f", sample.Timestamp().UnixMilli(), sample.Meas.Pressure, sample.Meas.Temperature, sample.Meas.Rh)
 }
 
 func main() {
+	if RunSubcommand() {
+		return
+	}
 	i2cSensor, pars, parsErr := GetDeviceAndParameters()
 	HandleTermintingError(parsErr)
 
@@ -69,13 +51,15 @@ func main() {
 		return
 	}
 
-	measDataCh := make(chan MeasurementResult, 100)
-	go func() {
-		for data := range measDataCh {
-			fmt.Printf("%s\n", data.ToCsv())
-		}
-	}()
-
-	runtimeErr := RunBME(&bmeDevice, pars.PollInterval, measDataCh)
+	sampler := BME280golib.CreateSampler(&bmeDevice, pars.PollInterval)
+	sampler.Config = pars.SensorConf
+	sampler.MaxSamples = pars.SampleCount
+	if pars.SensorConf.Mode == BME280golib.MODE_NORMAL {
+		sampler.Align = BME280golib.SAMPLERALIGN_CHIPCYCLE
+	}
+	runtimeErr := sampler.Run(context.Background(), func(sample BME280golib.Sample) error {
+		fmt.Printf("%s\n", ToCsv(sample))
+		return nil
+	})
 	HandleTermintingError(runtimeErr)
 }
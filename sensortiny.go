@@ -0,0 +1,72 @@
+//go:build tinygo
+
+/*
+Adapter to tinygo drivers.Sensor interface, so BME280 can be used from generic sensor code
+*/
+package BME280golib
+
+import (
+	"math"
+
+	"tinygo.org/x/drivers"
+)
+
+// measurementInto is implemented by BME280I2C, allows update without allocations
+type measurementInto interface {
+	ReadInto(result *HumTempPressureMeas) error
+}
+
+// BME280Sensor implements drivers.Sensor. Values follow drivers conventions, milli units as int32
+type BME280Sensor struct {
+	dev  BME280Device
+	meas HumTempPressureMeas
+}
+
+func CreateBME280Sensor(dev BME280Device) BME280Sensor {
+	return BME280Sensor{dev: dev}
+}
+
+// Update reads all channels at once, same burst read gives temperature, humidity and pressure
+func (p *BME280Sensor) Update(which drivers.Measurement) error {
+	if which&(drivers.Temperature|drivers.Humidity|drivers.Pressure) == 0 {
+		return nil
+	}
+	if d, ok := p.dev.(measurementInto); ok {
+		return d.ReadInto(&p.meas)
+	}
+	meas, err := p.dev.Read()
+	if err != nil {
+		return err
+	}
+	p.meas = meas
+	return nil
+}
+
+// toInt32 scales and saturates out of range (inf) values
+func toInt32(f float64, scale float64) int32 {
+	v := math.Round(f * scale)
+	if math.MaxInt32 < v {
+		return math.MaxInt32
+	}
+	if v < math.MinInt32 {
+		return math.MinInt32
+	}
+	return int32(v)
+}
+
+// Temperature returns temperature of last update in celsius milli degrees (°C/1000)
+func (p *BME280Sensor) Temperature() int32 {
+	return toInt32(p.meas.Temperature, 1000)
+}
+
+// Humidity returns relative humidity of last update in hundredths of a percent
+func (p *BME280Sensor) Humidity() int32 {
+	return toInt32(p.meas.Rh, 100)
+}
+
+// Pressure returns pressure of last update in milli pascals mPa
+func (p *BME280Sensor) Pressure() int32 {
+	return toInt32(p.meas.Pressure, 1000)
+}
+
+var _ drivers.Sensor = &BME280Sensor{}
@@ -0,0 +1,218 @@
+//go:build !tinygo
+
+/*
+Importing logic analyser captures decoded by sigrok/PulseView I2C protocol decoder
+
+Supports sigrok-cli annotation output (sigrok-cli -P i2c -A i2c) and PulseView annotation
+exports (text or CSV). Lines are recognized by decoder annotations like
+
+	i2c-1: Start
+	i2c-1: Address write: 76
+	i2c-1: Data write: F7
+	i2c-1: Start repeat
+	i2c-1: Address read: 76
+	i2c-1: Data read: 80
+	i2c-1: Stop
+*/
+package BME280golib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	sigrokAddressRe = regexp.MustCompile(`(?i)address (read|write)\s*:\s*(?:0x)?([0-9a-f]{1,2})\b`)
+	sigrokDataRe    = regexp.MustCompile(`(?i)data (read|write)\s*:\s*(?:0x)?([0-9a-f]{1,2})\b`)
+	sigrokStartRe   = regexp.MustCompile(`(?i)\b(start|start repeat|repeat start|repeated start)\s*("|,|$)`)
+	sigrokStopRe    = regexp.MustCompile(`(?i)\bstop\s*("|,|$)`)
+	sigrokNackRe    = regexp.MustCompile(`(?i)\bnack\s*("|,|$)`)
+)
+
+// sigrokDecoder reconstructs register transactions from bus events
+type sigrokDecoder struct {
+	deviceAddr   uint16 //0=any BME280 address
+	transactions []I2CTransaction
+
+	active      bool //Transfer is addressed to BME280
+	isRead      bool
+	data        []byte
+	nack        bool
+	pointer     byte //Register pointer on chip
+	pointerKnow bool
+}
+
+func (p *sigrokDecoder) addressMatch(addr uint16) bool {
+	if p.deviceAddr == 0 {
+		return addr == BME280DEVICEBIT0 || addr == BME280DEVICEBIT1
+	}
+	return addr == p.deviceAddr
+}
+
+// flush ends current transfer
+func (p *sigrokDecoder) flush() {
+	if !p.active {
+		return
+	}
+	p.active = false
+	if p.isRead {
+		if !p.pointerKnow {
+			return //Can not know what was read
+		}
+		t := I2CTransaction{Kind: I2CTRANSACTION_READ, Address: p.pointer, Count: byte(len(p.data)), Data: p.data}
+		if p.nack && len(p.data) == 0 {
+			t.Err, t.Errno = "address NACK", ERRNO_EREMOTEIO //What i2c-dev gives on NACK
+		}
+		p.transactions = append(p.transactions, t)
+		p.pointer += byte(len(p.data)) //Auto-increment
+		return
+	}
+
+	if p.nack && len(p.data) == 0 {
+		p.transactions = append(p.transactions, I2CTransaction{Kind: I2CTRANSACTION_WRITE, Err: "address NACK", Errno: ERRNO_EREMOTEIO, Data: []byte{0}})
+		return
+	}
+	//Write is register address + data pairs. Single register address sets pointer for next read
+	for i := 0; i < len(p.data); i += 2 {
+		p.pointer = p.data[i]
+		p.pointerKnow = true
+		if i+1 < len(p.data) {
+			p.transactions = append(p.transactions, I2CTransaction{Kind: I2CTRANSACTION_WRITE, Address: p.data[i], Data: []byte{p.data[i+1]}})
+		}
+	}
+}
+
+func (p *sigrokDecoder) line(s string) {
+	if m := sigrokAddressRe.FindStringSubmatch(s); m != nil {
+		addr, _ := strconv.ParseUint(m[2], 16, 8)
+		p.flush() //Missing repeated start
+		p.active = p.addressMatch(uint16(addr))
+		p.isRead = strings.EqualFold(m[1], "read")
+		p.data = []byte{}
+		p.nack = false
+		return
+	}
+	if m := sigrokDataRe.FindStringSubmatch(s); m != nil {
+		value, _ := strconv.ParseUint(m[2], 16, 8)
+		if p.active {
+			p.data = append(p.data, byte(value))
+		}
+		return
+	}
+	if sigrokNackRe.MatchString(s) {
+		p.nack = true
+		return
+	}
+	if sigrokStartRe.MatchString(s) || sigrokStopRe.MatchString(s) {
+		p.flush()
+	}
+}
+
+/*
+ParseSigrokI2C reads sigrok/PulseView I2C decoder output and reconstructs BME280 register transactions.
+deviceAddr is 7bit I2C address, 0 accepts both BME280 addresses
+*/
+func ParseSigrokI2C(r io.Reader, deviceAddr uint16) ([]I2CTransaction, error) {
+	dec := sigrokDecoder{deviceAddr: deviceAddr}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		dec.line(scanner.Text())
+	}
+	dec.flush()
+	return dec.transactions, scanner.Err()
+}
+
+// DecodedMeasurement is data register read found from session
+type DecodedMeasurement struct {
+	Transaction int //Index on transactions
+	Raw         RawMeas
+	Meas        HumTempPressureMeas
+}
+
+// DecodedSession is what can be reconstructed from register transactions
+type DecodedSession struct {
+	ChipID           byte
+	Config           BME280Config //Last written configuration
+	ConfigWrites     int          //How many times configuration registers were written
+	Calibration      CalibrationRegs
+	CalibrationValid bool //All calibration registers were read
+	Measurements     []DecodedMeasurement
+	Errors           []string //Failed transactions
+}
+
+/*
+DecodeBME280Session reconstructs configuration, calibration and compensated measurements from transactions.
+Transactions can be from ParseSigrokI2C or from recorded session
+*/
+func DecodeBME280Session(transactions []I2CTransaction) DecodedSession {
+	result := DecodedSession{}
+	var regs [256]byte
+	var known [256]bool
+
+	for i, t := range transactions {
+		if t.Err != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("#%v %s", i, t))
+			continue
+		}
+		switch t.Kind {
+		case I2CTRANSACTION_WRITE:
+			regs[t.Address] = t.Data[0]
+			known[t.Address] = true
+			switch t.Address {
+			case REGISTER_CTRL_HUM:
+				result.Config.Oversample_humidity = DecodeCtrlHumRegister(t.Data[0]).Osrs_h
+				result.ConfigWrites++
+			case REGISTER_CTRL_MEAS:
+				reg := DecodeCtrlMeasRegister(t.Data[0])
+				result.Config.Oversample_temperature = reg.Osrs_t
+				result.Config.Oversample_pressure = reg.Osrs_p
+				result.Config.Mode = reg.Mode
+				result.ConfigWrites++
+			case REGISTER_CONFIG:
+				reg := DecodeConfigRegister(t.Data[0])
+				result.Config.Standby = reg.T_sb
+				result.Config.Filter = reg.Filter
+				result.ConfigWrites++
+			}
+		case I2CTRANSACTION_READ:
+			for j, b := range t.Data {
+				regs[t.Address+byte(j)] = b
+				known[t.Address+byte(j)] = true
+			}
+			if t.Address <= REGISTER_ID && int(REGISTER_ID) < int(t.Address)+len(t.Data) {
+				result.ChipID = regs[REGISTER_ID]
+			}
+			if t.Address <= REGISTER_DATA && int(REGISTER_DATA)+8 <= int(t.Address)+len(t.Data) {
+				result.Measurements = append(result.Measurements, DecodedMeasurement{
+					Transaction: i,
+					Raw:         ParseRawMeas(regs[REGISTER_DATA : int(REGISTER_DATA)+8]),
+				})
+			}
+		}
+	}
+
+	result.CalibrationValid = known[REGISTER_CALIB_H1]
+	for i := 0; i < 24; i++ {
+		result.CalibrationValid = result.CalibrationValid && known[int(REGISTER_CALIB00)+i]
+	}
+	for i := 0; i < 7; i++ {
+		result.CalibrationValid = result.CalibrationValid && known[int(REGISTER_CALIB26)+i]
+	}
+	if !result.CalibrationValid {
+		return result
+	}
+	var errCalib error
+	result.Calibration, errCalib = ParseCalibration(regs[REGISTER_CALIB00:int(REGISTER_CALIB00)+24], regs[REGISTER_CALIB26:int(REGISTER_CALIB26)+7], regs[REGISTER_CALIB_H1])
+	if errCalib != nil {
+		result.CalibrationValid = false
+		return result
+	}
+	for i := range result.Measurements {
+		result.Measurements[i].Meas, _ = result.Measurements[i].Raw.Compensate(result.Calibration)
+	}
+	return result
+}
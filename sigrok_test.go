@@ -0,0 +1,90 @@
+//go:build !tinygo
+
+package BME280golib
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func parseSigrokFile(t *testing.T, name string, deviceAddr uint16) []I2CTransaction {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	transactions, err := ParseSigrokI2C(f, deviceAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transactions
+}
+
+// Same session captured from sigrok-cli and exported from PulseView. Datasheet calibration and raw values
+func TestSigrokSession(t *testing.T) {
+	expectedConfig := BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_temperature: OVRSAMPLE_2, Oversample_pressure: OVRSAMPLE_16, Mode: MODE_NORMAL, Standby: STANDBYDURATION_62_5, Filter: FILTER_4}
+	var sessions [][]I2CTransaction
+	for _, name := range []string{"testdata/sigrok-cli.txt", "testdata/pulseview.csv"} {
+		transactions := parseSigrokFile(t, name, 0)
+		sessions = append(sessions, transactions)
+		//id, 3 calibration reads, 3 config writes, address NACK and data read. Other device on bus is dropped
+		if len(transactions) != 9 {
+			t.Fatalf("%s: got %v transactions\n%v", name, len(transactions), transactions)
+		}
+		nack := transactions[7]
+		if nack.Kind != I2CTRANSACTION_WRITE || nack.Err == "" || nack.Errno != ERRNO_EREMOTEIO {
+			t.Errorf("%s: address NACK decoded as %s", name, nack)
+		}
+		data := transactions[8] //Register pointer write + repeated start + read
+		if data.Kind != I2CTRANSACTION_READ || data.Address != REGISTER_DATA || data.Count != 8 || data.Err != "" {
+			t.Errorf("%s: data read decoded as %s", name, data)
+		}
+
+		session := DecodeBME280Session(transactions)
+		if session.ChipID != ID_EXPECTED {
+			t.Errorf("%s: chip id 0x%02X", name, session.ChipID)
+		}
+		if session.Config != expectedConfig || session.ConfigWrites != 3 {
+			t.Errorf("%s: config %s written %v times, expected %s", name, session.Config, session.ConfigWrites, expectedConfig)
+		}
+		if !session.CalibrationValid {
+			t.Errorf("%s: calibration not found", name)
+		}
+		if len(session.Errors) != 1 {
+			t.Errorf("%s: errors %v", name, session.Errors)
+		}
+		if len(session.Measurements) != 1 {
+			t.Fatalf("%s: measurements %v", name, session.Measurements)
+		}
+		meas := session.Measurements[0]
+		if meas.Transaction != 8 || meas.Raw != (RawMeas{Temperature: 519888, Pressure: 415148, Humidity: 0x6A00}) {
+			t.Errorf("%s: raw measurement %v", name, meas)
+		}
+		if meas.Meas.Temperature < 25.07 || 25.09 < meas.Meas.Temperature || meas.Meas.Pressure < 100600 || 100700 < meas.Meas.Pressure {
+			t.Errorf("%s: compensated measurement %v", name, meas.Meas)
+		}
+	}
+	if !reflect.DeepEqual(sessions[0], sessions[1]) {
+		t.Errorf("sigrok-cli and PulseView give different transactions")
+	}
+}
+
+func TestSigrokAddressFilter(t *testing.T) {
+	if n := len(parseSigrokFile(t, "testdata/sigrok-cli.txt", BME280DEVICEBIT0)); n != 9 {
+		t.Errorf("0x%02X gives %v transactions", BME280DEVICEBIT0, n)
+	}
+	if n := len(parseSigrokFile(t, "testdata/sigrok-cli.txt", BME280DEVICEBIT1)); n != 0 {
+		t.Errorf("0x%02X gives %v transactions", BME280DEVICEBIT1, n)
+	}
+}
+
+// Without calibration reads on capture, only raw values are available
+func TestSigrokWithoutCalibration(t *testing.T) {
+	transactions := parseSigrokFile(t, "testdata/sigrok-cli.txt", 0)
+	session := DecodeBME280Session(transactions[4:])
+	if session.CalibrationValid || len(session.Measurements) != 1 || session.Measurements[0].Meas != (HumTempPressureMeas{}) {
+		t.Errorf("partial session decoded as %#v", session)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Supervisor for detecting brownout or spontaneous reset of BME280
+
+After supply glitch BME280 reverts silently to sleep mode with default config.
+Data registers keep last result and Read keeps returning stale data forever.
+*/
+package BME280golib
+
+import (
+	"fmt"
+	"time"
+)
+
+type SupervisorEventType int
+
+const (
+	SUPERVISOREVENT_CONFIGLOST SupervisorEventType = 0 //Configuration read back from chip differs from written
+	SUPERVISOREVENT_STUCKDATA  SupervisorEventType = 1 //Same raw data too long in normal mode
+)
+
+func (a SupervisorEventType) String() string {
+	switch a {
+	case SUPERVISOREVENT_CONFIGLOST:
+		return "config lost"
+	case SUPERVISOREVENT_STUCKDATA:
+		return "stuck data"
+	}
+	return "INVALID"
+}
+
+// SupervisorEvent is emitted each time when device is re-initialized
+type SupervisorEvent struct {
+	Time   time.Time
+	Reason SupervisorEventType
+	Detail string
+	Err    error //Non nil if re-initialization failed
+}
+
+func (a SupervisorEvent) String() string {
+	if a.Err != nil {
+		return fmt.Sprintf("%s %s: %s, re-init failed %v", a.Time.Format(time.RFC3339), a.Reason, a.Detail, a.Err)
+	}
+	return fmt.Sprintf("%s %s: %s, re-initialized", a.Time.Format(time.RFC3339), a.Reason, a.Detail)
+}
+
+/*
+BME280Supervisor wraps BME280Device and implements BME280Device.
+On Read it periodically reads back configuration (if device implements BME280ConfigReader)
+and checks that data is changing in normal mode. If reset is detected, SoftReset, calibration read
+and Configure are re-run.
+*/
+type BME280Supervisor struct {
+	dev           BME280Device
+	CheckInterval time.Duration               //How often configuration is read back. 0=on every read
+	StuckTimeout  time.Duration               //How long identical data is allowed in normal mode. 0=three cycles
+	OnEvent       func(event SupervisorEvent) //Optional, called when device is re-initialized
+
+	config     BME280Config
+	configured bool
+	lastCheck  time.Time
+	lastMeas   HumTempPressureMeas
+	lastChange time.Time
+	haveMeas   bool
+	reinits    int
+}
+
+func CreateBME280Supervisor(dev BME280Device, checkInterval time.Duration) BME280Supervisor {
+	return BME280Supervisor{dev: dev, CheckInterval: checkInterval}
+}
+
+// Reinits tells how many times device have been re-initialized
+func (p *BME280Supervisor) Reinits() int {
+	return p.reinits
+}
+
+func (p *BME280Supervisor) Close() error {
+	return p.dev.Close()
+}
+
+func (p *BME280Supervisor) Configure(config BME280Config) error {
+	err := p.dev.Configure(config)
+	if err != nil {
+		return err
+	}
+	p.config = config
+	p.configured = true
+	p.haveMeas = false
+	p.lastCheck = time.Now()
+	return nil
+}
+
+func (p *BME280Supervisor) SoftReset() error {
+	p.configured = false //Reset is intentional, user have to configure again
+	return p.dev.SoftReset()
+}
+
+func (p *BME280Supervisor) GetCalibration() (CalibrationRegs, error) {
+	return p.dev.GetCalibration()
+}
+
+func (p *BME280Supervisor) stuckTimeout() time.Duration {
+	if 0 < p.StuckTimeout {
+		return p.StuckTimeout
+	}
+	return 3 * p.config.CycleDuration()
+}
+
+func (p *BME280Supervisor) Read() (HumTempPressureMeas, error) {
+	tNow := time.Now()
+	if p.configured && p.CheckInterval <= tNow.Sub(p.lastCheck) {
+		p.lastCheck = tNow
+		errCheck := p.checkConfig()
+		if errCheck != nil {
+			return HumTempPressureMeas{}, errCheck
+		}
+	}
+
+	meas, err := p.dev.Read()
+	if err != nil {
+		return meas, err
+	}
+
+	if !p.configured || p.config.Mode != MODE_NORMAL {
+		return meas, nil
+	}
+
+	if !p.haveMeas || meas != p.lastMeas {
+		p.lastMeas = meas
+		p.lastChange = tNow
+		p.haveMeas = true
+		return meas, nil
+	}
+
+	stuckFor := tNow.Sub(p.lastChange)
+	if stuckFor <= p.stuckTimeout() {
+		return meas, nil
+	}
+
+	errReinit := p.reinit(SUPERVISOREVENT_STUCKDATA, fmt.Sprintf("identical data for %v", stuckFor))
+	if errReinit != nil {
+		return meas, errReinit
+	}
+	time.Sleep(p.config.MeasurementDurationMaximum()) //First result after re-init
+	return p.dev.Read()
+}
+
+// checkConfig compares config on chip to what is configured
+func (p *BME280Supervisor) checkConfig() error {
+	reader, ok := p.dev.(BME280ConfigReader)
+	if !ok {
+		return nil
+	}
+	onChip, err := reader.ReadConfig()
+	if err != nil {
+		return fmt.Errorf("config read back failed %w", err)
+	}
+	expected := p.config
+	if expected.Mode == MODE_FORCED && onChip.Mode == MODE_SLEEP { //Returns to sleep after forced measurement
+		onChip.Mode = MODE_FORCED
+	}
+	if onChip == expected {
+		return nil
+	}
+	return p.reinit(SUPERVISOREVENT_CONFIGLOST, fmt.Sprintf("on chip %s", onChip))
+}
+
+// reinit runs initialization sequence again and emits event
+func (p *BME280Supervisor) reinit(reason SupervisorEventType, detail string) error {
+	err := p.dev.SoftReset()
+	if err == nil {
+		_, err = p.dev.GetCalibration()
+	}
+	if err == nil {
+		err = p.dev.Configure(p.config)
+	}
+	p.reinits++
+	p.haveMeas = false
+	if p.OnEvent != nil {
+		p.OnEvent(SupervisorEvent{Time: time.Now(), Reason: reason, Detail: detail, Err: err})
+	}
+	if err != nil {
+		return fmt.Errorf("re-initialization after %s failed %w", reason, err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package BME280golib
+
+import (
+	"testing"
+	"time"
+)
+
+func createSupervised(t *testing.T, bus *fakeBME280, checkInterval time.Duration) (*BME280Supervisor, *[]SupervisorEvent) {
+	t.Helper()
+	dev, err := CreateBME280I2C(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	supervisor := CreateBME280Supervisor(&dev, checkInterval)
+	events := []SupervisorEvent{}
+	supervisor.OnEvent = func(event SupervisorEvent) {
+		events = append(events, event)
+	}
+	//Non-default standby and filter, config read back must match written bit positions
+	err = supervisor.Configure(BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_4, Oversample_temperature: OVRSAMPLE_2, Mode: MODE_NORMAL, Standby: STANDBYDURATION_0_5, Filter: FILTER_4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &supervisor, &events
+}
+
+func TestSupervisorHealthy(t *testing.T) {
+	bus := newFakeBME280()
+	supervisor, events := createSupervised(t, bus, 0)
+	supervisor.StuckTimeout = time.Millisecond
+	for i := 0; i < 5; i++ {
+		bus.setRaw(RawMeas{Temperature: 519888 + uint32(i), Pressure: 415148, Humidity: 0x6A00})
+		_, err := supervisor.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if len(*events) != 0 || supervisor.Reinits() != 0 {
+		t.Errorf("healthy device re-initialized %v times, events %v", supervisor.Reinits(), *events)
+	}
+}
+
+func TestSupervisorConfigLost(t *testing.T) {
+	bus := newFakeBME280()
+	supervisor, events := createSupervised(t, bus, 0)
+	configured := [3]byte{bus.regs[REGISTER_CTRL_HUM], bus.regs[REGISTER_CTRL_MEAS], bus.regs[REGISTER_CONFIG]}
+
+	bus.reset() //Brownout
+	_, err := supervisor.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*events) != 1 || (*events)[0].Reason != SUPERVISOREVENT_CONFIGLOST || (*events)[0].Err != nil {
+		t.Fatalf("expected one config lost event, got %v", *events)
+	}
+	if supervisor.Reinits() != 1 {
+		t.Errorf("reinits %v", supervisor.Reinits())
+	}
+	if got := [3]byte{bus.regs[REGISTER_CTRL_HUM], bus.regs[REGISTER_CTRL_MEAS], bus.regs[REGISTER_CONFIG]}; got != configured {
+		t.Errorf("re-initialized registers %X, expected %X", got, configured)
+	}
+
+	_, err = supervisor.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*events) != 1 {
+		t.Errorf("restored config caused new events %v", *events)
+	}
+}
+
+func TestSupervisorCheckInterval(t *testing.T) {
+	bus := newFakeBME280()
+	supervisor, events := createSupervised(t, bus, time.Hour)
+	bus.reset()
+	bus.setRaw(RawMeas{Temperature: 519888, Pressure: 415148, Humidity: 0x6A00})
+	_, err := supervisor.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*events) != 0 {
+		t.Errorf("config checked before interval, events %v", *events)
+	}
+}
+
+func TestSupervisorStuckData(t *testing.T) {
+	bus := newFakeBME280()
+	supervisor, events := createSupervised(t, bus, time.Hour) //Only stuck data detection is active
+	supervisor.StuckTimeout = 5 * time.Millisecond
+
+	_, err := supervisor.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = supervisor.Read()
+	if err != nil || len(*events) != 0 {
+		t.Fatalf("identical data within timeout gave %v, events %v", err, *events)
+	}
+	time.Sleep(10 * time.Millisecond)
+	_, err = supervisor.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*events) != 1 || (*events)[0].Reason != SUPERVISOREVENT_STUCKDATA {
+		t.Fatalf("expected one stuck data event, got %v", *events)
+	}
+	if bus.regs[REGISTER_CTRL_MEAS] == 0 {
+		t.Errorf("not configured after stuck data re-init")
+	}
+}
+
+// Stuck data detection is only for normal mode, forced mode data changes only when triggered
+func TestSupervisorForcedNotStuck(t *testing.T) {
+	bus := newFakeBME280()
+	supervisor, events := createSupervised(t, bus, 0)
+	supervisor.StuckTimeout = time.Millisecond
+	err := supervisor.Configure(BME280Config{Oversample_humidity: OVRSAMPLE_1, Oversample_pressure: OVRSAMPLE_1, Oversample_temperature: OVRSAMPLE_1, Mode: MODE_FORCED})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		bus.regs[REGISTER_CTRL_MEAS] &^= 3 //Chip returns to sleep after forced measurement
+		_, err = supervisor.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if len(*events) != 0 {
+		t.Errorf("forced mode caused events %v", *events)
+	}
+}
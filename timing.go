@@ -0,0 +1,99 @@
+/*
+Sample timestamping from chip timing.
+Conversion completion is seen as falling edge of measuring bit on status register. After that
+completions are predicted with measurement and standby durations. Every fresh read narrows estimate
+because completion must be between previous read and this read
+*/
+package BME280golib
+
+import (
+	"fmt"
+	"time"
+)
+
+// BME280TimingSyncer is implemented by devices that can synchronize to measurement cycle of chip
+type BME280TimingSyncer interface {
+	SyncTiming(timeout time.Duration) (time.Time, error)
+}
+
+/*
+SyncTiming polls status register until measuring bit goes from 1 to 0 (results transferred to data registers)
+and reads data. Returns estimated completion time. Works on normal mode and on forced mode after trigger
+*/
+func (p *BME280I2C) SyncTiming(timeout time.Duration) (time.Time, error) {
+	var status [1]byte
+	deadline := time.Now().Add(timeout)
+	measuring := false
+	var prevPoll time.Time
+	for time.Now().Before(deadline) {
+		err := p.dev.ReadRegsInto(REGISTER_STATUS, status[:])
+		if err != nil {
+			return time.Time{}, err
+		}
+		poll := time.Now()
+		if DecodeStatusRegister(status[0]).Measuring {
+			measuring = true
+		} else if measuring { //Falling edge between prevPoll and poll
+			var meas HumTempPressureMeas
+			p.fresh.lastRead = prevPoll
+			_, err = p.readTracked(&meas)
+			p.fresh.completed = prevPoll.Add(poll.Sub(prevPoll) / 2)
+			p.fresh.uncertainty = poll.Sub(prevPoll) / 2
+			p.fresh.changeSeen = poll
+			return p.fresh.completed, err
+		}
+		prevPoll = poll
+	}
+	return time.Time{}, fmt.Errorf("no measurement completion seen in %v", timeout)
+}
+
+// estimateCompletion gives completion time and uncertainty of conversion seen first at readTime
+func (p *BME280I2C) estimateCompletion(readTime time.Time) (time.Time, time.Duration) {
+	lo := p.earliestCompletion(readTime)
+	hi := readTime
+	if lo.IsZero() {
+		return time.Time{}, 0
+	}
+
+	//Triggered forced measurement takes from typical to maximum measurement time
+	if p.configured && p.config.Mode == MODE_FORCED && p.fresh.lastRead.Before(p.configuredAt) {
+		_, measurement := p.config.measurementCharge()
+		if limit := p.configuredAt.Add(measurement); lo.Before(limit) && limit.Before(hi) {
+			lo = limit
+		}
+		if limit := p.configuredAt.Add(p.config.MeasurementDurationMaximum()); limit.Before(hi) && lo.Before(limit) {
+			hi = limit
+		}
+	}
+
+	//Previous conversion is anchor for predicting this
+	if p.configured && p.config.Mode == MODE_NORMAL && p.fresh.valid && !p.fresh.completed.IsZero() && p.configuredAt.Before(p.fresh.changeSeen) {
+		cycle := p.config.MeasurementDurationTypical()
+		drift := p.config.CycleDuration() - cycle //Measurement time varies between typical and maximum
+		//Predictions overlapping reads. If many cycles are possible, hull of them
+		var hullLo, hullHi time.Time
+		kLatest := (hi.Sub(p.fresh.completed) + p.fresh.uncertainty) / cycle
+		for k := max(kLatest-1, 1); k <= kLatest+1; k++ {
+			predicted := p.fresh.completed.Add(k * cycle)
+			u := p.fresh.uncertainty + time.Duration(k)*drift
+			candLo, candHi := predicted.Add(-u), predicted.Add(u)
+			if candLo.Before(lo) {
+				candLo = lo
+			}
+			if hi.Before(candHi) {
+				candHi = hi
+			}
+			if candHi.Before(candLo) {
+				continue
+			}
+			if hullLo.IsZero() {
+				hullLo = candLo
+			}
+			hullHi = candHi
+		}
+		if !hullLo.IsZero() { //Prediction fails if chip have been reset
+			lo, hi = hullLo, hullHi
+		}
+	}
+	return lo.Add(hi.Sub(lo) / 2), hi.Sub(lo) / 2
+}
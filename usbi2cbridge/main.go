@@ -0,0 +1,57 @@
+//go:build tinygo
+
+/*
+Firmware turning TinyGo board into USB-I2C adapter for BME280.
+Serves raw register reads and writes over USB CDC serial with same framed protocol as ServeI2CRemote.
+Host side uses OpenI2CSerial
+
+Tested on wio terminal. Change pins and I2C address if needed
+*/
+
+package main
+
+import (
+	"machine"
+	"time"
+
+	"github.com/hjkoskel/BME280golib"
+)
+
+// serialPort makes blocking io.ReadWriter from machine.Serial
+type serialPort struct{}
+
+func (serialPort) Read(b []byte) (int, error) {
+	for machine.Serial.Buffered() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	n := 0
+	for n < len(b) && 0 < machine.Serial.Buffered() {
+		c, err := machine.Serial.ReadByte()
+		if err != nil {
+			break
+		}
+		b[n] = c
+		n++
+	}
+	return n, nil
+}
+
+func (serialPort) Write(b []byte) (int, error) {
+	return machine.Serial.Write(b)
+}
+
+func main() {
+	i2cConnect := machine.I2C1
+	errI2CConfigure := i2cConnect.Configure(machine.I2CConfig{
+		SCL: machine.SCL1_PIN,
+		SDA: machine.SDA1_PIN,
+	})
+	for errI2CConfigure != nil { //Nothing to serve. Do not print, host would see it as garbage
+		time.Sleep(time.Second)
+	}
+
+	bus := BME280golib.CreateI2CTiny(i2cConnect, BME280golib.BME280DEVICEBIT0) //TODO change to BME280golib.BME280DEVICEBIT1 if needed
+	for {
+		BME280golib.ServeI2CRemote(serialPort{}, &bus) //Host closed session or garbage on line, start again
+	}
+}
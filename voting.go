@@ -0,0 +1,249 @@
+/*
+Voting between redundant sensors measuring same air.
+Channels are fused with median or trimmed mean. Sensor that disagrees with fused value more than
+tolerance for longer than window is excluded, so one drifting or broken sensor does not corrupt result
+*/
+package BME280golib
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+type FusionMethod byte
+
+const (
+	FUSION_MEDIAN      FusionMethod = 0
+	FUSION_TRIMMEDMEAN FusionMethod = 1
+)
+
+func (a FusionMethod) String() string {
+	switch a {
+	case FUSION_MEDIAN:
+		return "median"
+	case FUSION_TRIMMEDMEAN:
+		return "trimmed mean"
+	}
+	return "INVALID"
+}
+
+// fuse values by method. Infinite values (out of range) are ignored if there are finite values
+func (a FusionMethod) fuse(values []float64, trim float64) float64 {
+	finite := make([]float64, 0, len(values))
+	for _, v := range values {
+		if !math.IsInf(v, 0) && !math.IsNaN(v) {
+			finite = append(finite, v)
+		}
+	}
+	if len(finite) == 0 {
+		if len(values) == 0 {
+			return math.NaN()
+		}
+		return values[0]
+	}
+	sort.Float64s(finite)
+	n := len(finite)
+	if a == FUSION_TRIMMEDMEAN {
+		cut := int(math.Ceil(float64(n)*trim - 1e-9)) //Rounded up, 3 sensors at trim 0.2 drops one from both ends. Epsilon for float error like 10*0.3
+		if n <= 2*cut {
+			cut = (n - 1) / 2
+		}
+		sum := 0.0
+		for _, v := range finite[cut : n-cut] {
+			sum += v
+		}
+		return sum / float64(n-2*cut)
+	}
+	if n%2 == 0 {
+		return (finite[n/2-1] + finite[n/2]) / 2
+	}
+	return finite[n/2]
+}
+
+// VotingEvent is emitted when sensor is excluded from voting
+type VotingEvent struct {
+	Time      time.Time
+	Name      string
+	Deviation HumTempPressureMeas //Absolute deviation from fused value when excluded
+	Since     time.Time           //When disagreement started
+}
+
+func (a VotingEvent) String() string {
+	return fmt.Sprintf("%s %s excluded, disagreeing since %s by %.2fC %.2f%%RH %.1fPa",
+		a.Time.Format(time.RFC3339), a.Name, a.Since.Format(time.RFC3339), a.Deviation.Temperature, a.Deviation.Rh, a.Deviation.Pressure)
+}
+
+// FusedMeas is result of voting
+type FusedMeas struct {
+	Time       time.Time
+	Meas       HumTempPressureMeas
+	Used       []string                       //Sensors used on fusion
+	Deviations map[string]HumTempPressureMeas //Absolute deviation of each used sensor from fused value
+}
+
+/*
+SensorVoter fuses readings of SensorRecord. Tolerance is maximum absolute deviation from fused value
+per channel, 0 means that channel is not checked. Exclusion needs at least MinSensors voting so there is majority
+*/
+type SensorVoter struct {
+	Method       FusionMethod
+	TrimFraction float64             //Portion removed from both ends on trimmed mean. Rounded up, at least one value if not 0
+	Tolerance    HumTempPressureMeas //Maximum deviation per channel, 0=not checked
+	Window       time.Duration       //How long sensor is allowed to disagree before exclusion
+	MinSensors   int                 //Minimum number of sensors voting when excluding
+	OnEvent      func(event VotingEvent)
+
+	disagreeSince map[string]time.Time
+	excluded      map[string]bool
+}
+
+func CreateSensorVoter(tolerance HumTempPressureMeas, window time.Duration) SensorVoter {
+	return SensorVoter{
+		Method:        FUSION_MEDIAN,
+		TrimFraction:  0.2,
+		Tolerance:     tolerance,
+		Window:        window,
+		MinSensors:    3,
+		disagreeSince: make(map[string]time.Time),
+		excluded:      make(map[string]bool),
+	}
+}
+
+// Excluded lists excluded sensors, sorted
+func (p *SensorVoter) Excluded() []string {
+	result := []string{}
+	for name := range p.excluded {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Readmit takes excluded sensor back to voting, like after it is replaced
+func (p *SensorVoter) Readmit(name string) {
+	delete(p.excluded, name)
+	delete(p.disagreeSince, name)
+}
+
+func exceeds(deviation float64, tolerance float64) bool {
+	return 0 < tolerance && tolerance < deviation //Out of range gives Inf. NaN when both are out of range, agrees
+}
+
+// severity is largest deviation relative to tolerance on checked channels
+func (p *SensorVoter) severity(deviation HumTempPressureMeas) float64 {
+	result := 0.0
+	for _, ch := range [][2]float64{
+		{deviation.Temperature, p.Tolerance.Temperature},
+		{deviation.Rh, p.Tolerance.Rh},
+		{deviation.Pressure, p.Tolerance.Pressure}} {
+		if exceeds(ch[0], ch[1]) {
+			result = max(result, ch[0]/ch[1])
+		}
+	}
+	return result
+}
+
+/*
+Vote fuses valid readings of non-excluded sensors and updates disagreement tracking. Error if there are no readings.
+At most one sensor, the worst disagreeing, is excluded per vote. Then rest still have majority on next vote
+*/
+func (p *SensorVoter) Vote(record SensorRecord) (FusedMeas, error) {
+	if p.excluded == nil { //Not created with CreateSensorVoter
+		p.disagreeSince = make(map[string]time.Time)
+		p.excluded = make(map[string]bool)
+	}
+	readings := []SensorReading{}
+	for _, reading := range record.Readings {
+		if reading.Err == nil && !p.excluded[reading.Name] {
+			readings = append(readings, reading)
+		}
+	}
+	if len(readings) == 0 {
+		return FusedMeas{Time: record.Time}, fmt.Errorf("no valid readings for voting %v", record.Err())
+	}
+
+	temperatures := make([]float64, len(readings))
+	rhs := make([]float64, len(readings))
+	pressures := make([]float64, len(readings))
+	result := FusedMeas{Time: record.Time, Used: make([]string, len(readings)), Deviations: make(map[string]HumTempPressureMeas)}
+	for i, reading := range readings {
+		temperatures[i] = reading.Meas.Temperature
+		rhs[i] = reading.Meas.Rh
+		pressures[i] = reading.Meas.Pressure
+		result.Used[i] = reading.Name
+	}
+	result.Meas = HumTempPressureMeas{
+		Temperature: p.Method.fuse(temperatures, p.TrimFraction),
+		Rh:          p.Method.fuse(rhs, p.TrimFraction),
+		Pressure:    p.Method.fuse(pressures, p.TrimFraction),
+	}
+
+	var worst *VotingEvent
+	worstSeverity := 0.0
+	for _, reading := range readings {
+		deviation := reading.Meas.AbsDiff(result.Meas)
+		result.Deviations[reading.Name] = deviation
+		disagree := exceeds(deviation.Temperature, p.Tolerance.Temperature) ||
+			exceeds(deviation.Rh, p.Tolerance.Rh) ||
+			exceeds(deviation.Pressure, p.Tolerance.Pressure)
+		if !disagree {
+			delete(p.disagreeSince, reading.Name)
+			continue
+		}
+		since, haz := p.disagreeSince[reading.Name]
+		if !haz {
+			p.disagreeSince[reading.Name] = record.Time
+			since = record.Time
+		}
+		if severity := p.severity(deviation); p.Window <= record.Time.Sub(since) && worstSeverity < severity {
+			worst = &VotingEvent{Time: record.Time, Name: reading.Name, Deviation: deviation, Since: since}
+			worstSeverity = severity
+		}
+	}
+	//All readings are voting, none is excluded yet on this vote
+	if worst != nil && p.MinSensors <= len(readings) {
+		p.excluded[worst.Name] = true
+		if p.OnEvent != nil {
+			p.OnEvent(*worst)
+		}
+	}
+	return result, nil
+}
+
+/*
+RedundantSensor implements BME280Device by voting over sensors of SensorManager.
+Read gives fused result. Configure fails if any sensor fails, Read still works with rest of sensors
+*/
+type RedundantSensor struct {
+	Manager SensorManager
+	Voter   SensorVoter
+}
+
+func CreateRedundantSensor(manager SensorManager, voter SensorVoter) RedundantSensor {
+	return RedundantSensor{Manager: manager, Voter: voter}
+}
+
+func (p *RedundantSensor) Configure(config BME280Config) error {
+	p.Manager.Config = config
+	return p.Manager.Configure()
+}
+
+func (p *RedundantSensor) Read() (HumTempPressureMeas, error) {
+	fused, err := p.Voter.Vote(p.Manager.Sample())
+	return fused.Meas, err
+}
+
+func (p *RedundantSensor) SoftReset() error {
+	return p.Manager.SoftReset()
+}
+
+// GetCalibration is not available, every sensor have own calibration
+func (p *RedundantSensor) GetCalibration() (CalibrationRegs, error) {
+	return CalibrationRegs{}, fmt.Errorf("redundant sensor do not have single calibration")
+}
+
+func (p *RedundantSensor) Close() error {
+	return p.Manager.Close()
+}
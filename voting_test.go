@@ -0,0 +1,112 @@
+package BME280golib
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func pressureRecord(t0 time.Time, pressures ...float64) SensorRecord {
+	result := SensorRecord{Time: t0}
+	for i, pressure := range pressures {
+		result.Readings = append(result.Readings, SensorReading{Name: fmt.Sprintf("%c", 'a'+i), Meas: HumTempPressureMeas{Temperature: 20, Rh: 40, Pressure: pressure}})
+	}
+	return result
+}
+
+// One vote must not exclude majority
+func TestVoteExcludesOnePerVote(t *testing.T) {
+	voter := CreateSensorVoter(HumTempPressureMeas{Pressure: 3}, 0)
+	events := []VotingEvent{}
+	voter.OnEvent = func(event VotingEvent) { events = append(events, event) }
+	t0 := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := voter.Vote(pressureRecord(t0.Add(time.Duration(i)*time.Second), 100000, 100005, 100010))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(voter.Excluded()) != 1 || len(events) != 1 {
+		t.Errorf("excluded %v events %v, expected one", voter.Excluded(), events)
+	}
+}
+
+func TestVoteExcludesWorst(t *testing.T) {
+	voter := CreateSensorVoter(HumTempPressureMeas{Temperature: 0.5, Pressure: 10}, 2*time.Second)
+	t0 := time.Now()
+	var fused FusedMeas
+	var err error
+	for i := 0; i <= 3; i++ {
+		record := pressureRecord(t0.Add(time.Duration(i)*time.Second), 100000, 100020, 100002, 99999, math.Inf(1))
+		record.Readings[0].Meas.Temperature = 21 //a disagrees on temperature, b on pressure and e is out of range
+		fused, err = voter.Vote(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < 2 && len(voter.Excluded()) != 0 {
+			t.Fatalf("excluded %v before window", voter.Excluded())
+		}
+	}
+	//Out of range e is excluded on third vote, a on fourth (temperature 2x tolerance, b pressure 1.9x)
+	if excluded := voter.Excluded(); len(excluded) != 2 || excluded[0] != "a" || excluded[1] != "e" {
+		t.Errorf("excluded %v", voter.Excluded())
+	}
+	if fused.Meas.Pressure < 99999 || 100020 < fused.Meas.Pressure {
+		t.Errorf("fused pressure %v", fused.Meas.Pressure)
+	}
+}
+
+// Exclusion stops when there is no majority left
+func TestVoteMinSensors(t *testing.T) {
+	voter := CreateSensorVoter(HumTempPressureMeas{Pressure: 1}, 0)
+	t0 := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err := voter.Vote(pressureRecord(t0.Add(time.Duration(i)*time.Second), 100000, 100100, 100200, 100300))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(voter.Excluded()) != 2 {
+		t.Errorf("excluded %v, expected 2 of 4 with MinSensors 3", voter.Excluded())
+	}
+}
+
+func TestFuseTrimmedMean(t *testing.T) {
+	for _, tc := range []struct {
+		values   []float64
+		trim     float64
+		expected float64
+	}{
+		{[]float64{20, 20.2, 30}, 0.2, 20.2}, //Outlier of three sensors is trimmed
+		{[]float64{20, 20.2, 30}, 0, (20 + 20.2 + 30) / 3},
+		{[]float64{20, 21}, 0.2, 20.5},
+		{[]float64{10, 20, 21, 22, 90}, 0.2, 21},
+		{[]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 0.3, 5.5},
+		{[]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 100}, 0.1, 5.5},
+	} {
+		if got := FUSION_TRIMMEDMEAN.fuse(tc.values, tc.trim); math.Abs(got-tc.expected) > 1e-9 {
+			t.Errorf("%v trim %v: got %v, expected %v", tc.values, tc.trim, got, tc.expected)
+		}
+	}
+}
+
+func TestVoteTrimmedMeanThreeSensors(t *testing.T) {
+	voter := CreateSensorVoter(HumTempPressureMeas{Pressure: 10}, time.Second)
+	voter.Method = FUSION_TRIMMEDMEAN
+	t0 := time.Now()
+	var fused FusedMeas
+	var err error
+	for i := 0; i < 2; i++ {
+		fused, err = voter.Vote(pressureRecord(t0.Add(time.Duration(i)*time.Second), 100000, 100004, 100500))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fused.Meas.Pressure != 100004 {
+			t.Errorf("outlier affects fused pressure %v", fused.Meas.Pressure)
+		}
+	}
+	if excluded := voter.Excluded(); len(excluded) != 1 || excluded[0] != "c" {
+		t.Errorf("excluded %v", excluded)
+	}
+}